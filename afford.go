@@ -0,0 +1,31 @@
+package main
+
+import "fmt"
+
+// doAffordability finds the highest price a monthly -budget affords: what's
+// left of it after the taxes and insurance pays off the largest principal,
+// to which the -down-payment adds.
+func doAffordability() error {
+	if !isProvided("budget", "periods", "interest") {
+		return underSpecified("budget", "periods", "interest")
+	}
+
+	if periods <= 0 {
+		return outOfRange("periods")
+	}
+
+	extras := moneyOf(monthlyTax).Add(moneyOf(monthlyInsurance))
+	budgeted := moneyOf(budget).Sub(extras)
+	if budgeted <= 0 {
+		return outOfRange("budget", "monthly-tax", "monthly-insurance")
+	}
+
+	payment = budgeted.Float64()
+	principal = getAmortizer().annuityPrincipal()
+
+	fmt.Fprintf(stdout, msg("afford-budget"), moneyOf(budget), extras, budgeted)
+	fmt.Fprintf(stdout, msg("afford-principal"), moneyOf(principal))
+	fmt.Fprintf(stdout, msg("afford-price"), moneyOf(principal), moneyOf(downPayment), moneyOf(principal+downPayment))
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// auditRecord is the line appended to -audit-log for every calculation.
+type auditRecord struct {
+	SchemaVersion int               `json:"schema_version"`
+	Time          time.Time         `json:"time"`
+	Command       string            `json:"command"`
+	Inputs        map[string]string `json:"inputs"`
+	Output        string            `json:"output"`
+	Error         string            `json:"error,omitempty"`
+}
+
+// audit tees stdout while a calculation runs, so that its output can be
+// recorded along with the inputs.
+type audit struct {
+	record auditRecord
+	stdout io.Writer
+	out    bytes.Buffer
+}
+
+// startAudit returns nil without -audit-log, which finish accepts.
+func startAudit(fs *flag.FlagSet) *audit {
+	if auditLog == "" {
+		return nil
+	}
+
+	a := &audit{record: auditRecord{
+		SchemaVersion: schemaVersion,
+		Time:          now(),
+		Command:       canonicalCommand(givenFlags(fs)),
+		Inputs:        make(map[string]string),
+	}}
+
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name != "audit-log" {
+			a.record.Inputs[f.Name] = flagValue(f)
+		}
+	})
+
+	a.stdout = stdout
+	stdout = io.MultiWriter(a.stdout, &a.out)
+
+	return a
+}
+
+// finish restores stdout and appends the record, warning rather than
+// failing when it can't be written.
+func (a *audit) finish(calcErr error) {
+	if a == nil {
+		return
+	}
+
+	stdout = a.stdout
+
+	a.record.Output = a.out.String()
+	if calcErr != nil {
+		a.record.Error = calcErr.Error()
+	}
+
+	if err := appendAuditRecord(auditLog, a.record); err != nil {
+		fmt.Fprintf(stderr, msg("audit-failed"), err)
+	}
+}
+
+// auditParseError records a command line that failed before calculating,
+// when its flags couldn't be parsed, under the given -audit-log if any.
+func auditParseError(path string, args []string, parseErr error) {
+	if path == "" {
+		return
+	}
+
+	rec := auditRecord{
+		SchemaVersion: schemaVersion,
+		Time:          now(),
+		Command:       strings.Join(append([]string{filepath.Base(os.Args[0])}, args...), " "),
+		Error:         parseErr.Error(),
+	}
+
+	if err := appendAuditRecord(path, rec); err != nil {
+		fmt.Fprintf(stderr, msg("audit-failed"), err)
+	}
+}
+
+func appendAuditRecord(path string, rec auditRecord) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	if err := json.NewEncoder(w).Encode(rec); err != nil {
+		return err
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	return f.Close()
+}
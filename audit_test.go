@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuditLog(t *testing.T) {
+	log := filepath.Join(t.TempDir(), "audit.log")
+
+	for _, args := range []string{
+		"--type=annuity --principal=1000 --periods=12 --interest=5",
+		"--type=annuity --principal=1000 --periods=12",
+	} {
+		runArgs(t, args+" --audit-log="+log)
+	}
+
+	data, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("%d records:\n%s", len(lines), data)
+	}
+
+	var records [2]auditRecord
+	for k, line := range lines {
+		if err := json.Unmarshal([]byte(line), &records[k]); err != nil {
+			t.Fatalf("%v in %s", err, line)
+		}
+	}
+
+	ok, failed := records[0], records[1]
+	if ok.SchemaVersion != schemaVersion || !ok.Time.Equal(time.Date(2024, time.January, 15, 12, 0, 0, 0, time.UTC)) ||
+		!strings.HasSuffix(ok.Command, " --interest=5 --periods=12 --principal=1000 --type=annuity") {
+		t.Errorf("record: %+v", ok)
+	}
+	if ok.Inputs["principal"] != "1000" || ok.Inputs["interest"] != "5" || len(ok.Inputs) != 4 {
+		t.Errorf("inputs: %v", ok.Inputs)
+	}
+	if ok.Output != "Your annuity payment = 86!\nOverpayment = 32\n" || ok.Error != "" {
+		t.Errorf("output %q, error %q", ok.Output, ok.Error)
+	}
+	if failed.Error != "Incorrect parameters" || failed.Inputs["interest"] != "" {
+		t.Errorf("failed record: %+v", failed)
+	}
+
+	// a log that can't be written warns without failing the calculation
+	out, errOut, code := runArgs(t, "--type=annuity --principal=1000 --periods=12 --interest=5 --audit-log="+t.TempDir())
+	if out != "Your annuity payment = 86!\nOverpayment = 32\n" || code != 0 || !strings.HasPrefix(errOut, "Could not write the audit log: ") {
+		t.Errorf("exit %d, %q, %q", code, out, errOut)
+	}
+}
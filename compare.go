@@ -0,0 +1,36 @@
+package main
+
+import "fmt"
+
+// doFrequencyComparison pays the loan monthly and then biweekly with half
+// the monthly payment, which comes to one extra monthly payment a year.
+func doFrequencyComparison() error {
+	if !isProvided("principal", "periods", "interest") {
+		return underSpecified("principal", "periods", "interest")
+	}
+
+	if paymentsPerYear != 12 || periods <= 0 {
+		return outOfRange("payment-frequency", "periods")
+	}
+
+	payment = getAmortizer().annuityPayment()
+	monthly := getAmortizer().annuitySchedule(moneyOf(payment))
+	_, monthlyInterest := scheduleTotals(monthly)
+	monthlyMonths := paymentMonths(len(monthly))
+
+	half := moneyOf(payment / 2).Ceil()
+	savedPeriods := periods
+
+	paymentsPerYear, periods = 26, (periods*26+11)/12
+	biweekly := getAmortizer().annuitySchedule(half)
+	_, biweeklyInterest := scheduleTotals(biweekly)
+	biweeklyMonths := paymentMonths(len(biweekly))
+
+	paymentsPerYear, periods = 12, savedPeriods
+
+	fmt.Fprintf(stdout, msg("compare-monthly"), moneyOf(payment), formatPeriods(monthlyMonths), monthlyInterest)
+	fmt.Fprintf(stdout, msg("compare-biweekly"), half, formatPeriods(biweeklyMonths), biweeklyInterest)
+	fmt.Fprintf(stdout, msg("compare-saving"), formatPeriods(monthlyMonths-biweeklyMonths), monthlyInterest.Sub(biweeklyInterest))
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// displayResultDiff prints the figures that changed since the JSON result
+// saved in -compare-to.
+func displayResultDiff(current Result) error {
+	data, err := os.ReadFile(compareTo)
+	if err != nil {
+		return fmt.Errorf(msg("compare-unreadable"), compareTo, err)
+	}
+
+	var prior Result
+	if err := json.Unmarshal(data, &prior); err != nil {
+		return fmt.Errorf(msg("compare-unreadable"), compareTo, err)
+	}
+
+	if prior.SchemaVersion != schemaVersion {
+		return fmt.Errorf(msg("compare-incompatible"), compareTo, prior.SchemaVersion, schemaVersion)
+	}
+
+	changes := [][3]string{
+		{msg("compare-payment"), prior.Payment.String(), current.Payment.String()},
+		{msg("compare-principal"), prior.Principal.String(), current.Principal.String()},
+		{msg("compare-periods"), strconv.Itoa(prior.Periods), strconv.Itoa(current.Periods)},
+		{msg("compare-interest"), strconv.FormatFloat(prior.Interest, 'f', -1, 64),
+			strconv.FormatFloat(current.Interest, 'f', -1, 64)},
+		{msg("compare-overpayment"), prior.Overpayment.String(), current.Overpayment.String()},
+	}
+
+	fmt.Fprintln(stdout)
+
+	changed := false
+	for _, c := range changes {
+		if c[1] != c[2] {
+			fmt.Fprintf(stdout, msg("compare-changed"), c[0], c[1], c[2])
+			changed = true
+		}
+	}
+
+	if !changed {
+		fmt.Fprintf(stdout, msg("compare-unchanged"), compareTo)
+	}
+
+	return nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCompareTo(t *testing.T) {
+	const loan = "--type=annuity --principal=10000 --periods=60 "
+
+	saved, _, _ := runArgs(t, loan+"--interest=6 --format=json")
+	path := writeFile(t, "result.json", saved)
+
+	out, _, _ := runArgs(t, loan+"--interest=7 --compare-to="+path)
+	want := "Your annuity payment = 199!\nOverpayment = 1940\n\n" +
+		"Payment changed from 194 to 199\nInterest changed from 6 to 7\nOverpayment changed from 1640 to 1940\n"
+	if out != want {
+		t.Errorf("a changed rate:\n%s\nwant\n%s", out, want)
+	}
+
+	if out, _, _ := runArgs(t, loan+"--interest=6 --compare-to="+path); !strings.HasSuffix(out, "\n\nNothing changed since "+path+"\n") {
+		t.Errorf("the same loan:\n%s", out)
+	}
+
+	for _, c := range []struct {
+		path, want string
+	}{
+		{filepath.Join(t.TempDir(), "missing.json"), "Cannot read the result in "},
+		{writeFile(t, "broken.json", "{"), "Cannot read the result in "},
+		{writeFile(t, "future.json", `{"schema_version":9}`), "has schema version 9, not 1"},
+	} {
+		out, _, _ := runArgs(t, loan+"--interest=6 --compare-to="+c.path)
+		if !strings.HasPrefix(out, "Your annuity payment = 194!\nOverpayment = 1640\n") || !strings.Contains(out, c.want) {
+			t.Errorf("%s:\n%s", c.path, out)
+		}
+	}
+}
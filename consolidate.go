@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+type consolidatedLoan struct {
+	name      string
+	principal float64
+	interest  float64
+	periods   int
+	payment   float64
+}
+
+// doConsolidation prints the loans of -consolidate and the principal
+// weighted rate and blended payment of all of them.
+func doConsolidation() error {
+	loans, err := readLoans(consolidateFile)
+	if err != nil {
+		return err
+	}
+
+	var total, weighted float64
+	var blended Money
+
+	for k := range loans {
+		principal, interest, periods = loans[k].principal, loans[k].interest, loans[k].periods
+		if principal > 0 {
+			loans[k].payment = getAmortizer().annuityPayment()
+		}
+
+		// loans paid off already carry no weight
+		total += principal
+		weighted += principal * interest
+		blended = blended.Add(moneyOf(loans[k].payment))
+	}
+
+	if total == 0 {
+		return outOfRange("consolidate")
+	}
+
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+
+	fmt.Fprintln(w, msg("consolidate-header"))
+
+	for _, l := range loans {
+		fmt.Fprintf(w, "%s\t%s\t%g%%\t%d\t%s\t\n", l.name, moneyOf(l.principal), l.interest, l.periods, moneyOf(l.payment))
+	}
+
+	w.Flush()
+
+	fmt.Fprintln(stdout)
+	fmt.Fprintf(stdout, msg("consolidate-principal"), moneyOf(total))
+	fmt.Fprintf(stdout, msg("consolidate-rate"), weighted/total)
+	fmt.Fprintf(stdout, msg("consolidate-payment"), blended)
+
+	return nil
+}
+
+// readLoans loads "name,principal,rate,term" rows, skipping an optional
+// header row.
+func readLoans(path string) ([]consolidatedLoan, error) {
+	var loans []consolidatedLoan
+
+	err := readRecords(path, "loans", 4, []int{1, 2, 3}, func(record []string) error {
+		l, err := parseLoan(record)
+		loans = append(loans, l)
+		return err
+	})
+
+	return loans, err
+}
+
+func parseLoan(record []string) (consolidatedLoan, error) {
+	principal, err := parseFinite(record[1])
+	if err != nil || principal < 0 {
+		return consolidatedLoan{}, outOfRange("consolidate")
+	}
+
+	rate, err := parseFinite(record[2])
+	if err != nil || rate < 0 {
+		return consolidatedLoan{}, outOfRange("consolidate")
+	}
+
+	term, err := strconv.Atoi(record[3])
+	if err != nil || term <= 0 {
+		return consolidatedLoan{}, outOfRange("consolidate")
+	}
+
+	if centsMode {
+		principal /= 100
+	}
+
+	return consolidatedLoan{name: strings.TrimSpace(record[0]), principal: principal, interest: rate, periods: term}, nil
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestConsolidatedRate weights 3% on 30000 and 6% on 10000 to 3.75%, with a
+// paid-off loan carrying no weight.
+func TestConsolidatedRate(t *testing.T) {
+	path := writeFile(t, "loans.csv", "name,principal,rate,term\ncar,10000,6,60\nhome,30000,3,120\ncard,0,20,12\n")
+
+	out, _, _ := runArgs(t, "--consolidate="+path)
+	for _, want := range []string{"Total principal = 40000", "Weighted average rate = 3.7500%", "Blended monthly payment = 484"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("no %q in:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// corpusHeader names the flags of the seed corpus columns, so that -batch
+// can read the corpus back.
+var corpusHeader = []string{"type", "principal", "periods", "interest"}
+
+// corpus spans the edge cases of the calculations: zero and high
+// rates, the shortest and long terms, and tiny and huge principals.
+var corpus = [][]string{
+	{"annuity", "1000000", "60", "10"},
+	{"annuity", "120000", "12", "0"},
+	{"annuity", "10000", "24", "99"},
+	{"annuity", "5000", "1", "5"},
+	{"annuity", "300000", "1200", "4"},
+	{"annuity", "1", "12", "5"},
+	{"annuity", "999999999999", "360", "6"},
+	{"annuity", "500000", "360", "0.01"},
+	{"diff", "500000", "8", "7.8"},
+	{"diff", "120000", "12", "0"},
+	{"diff", "10000", "24", "99"},
+	{"diff", "5000", "1", "5"},
+	{"diff", "300000", "1200", "4"},
+	{"diff", "1", "12", "5"},
+	{"diff", "999999999999", "360", "6"},
+}
+
+// displaySeedCorpus writes the seed corpus as CSV for -seed-corpus.
+func displaySeedCorpus() error {
+	w := csv.NewWriter(stdout)
+
+	w.Write(corpusHeader)
+	w.WriteAll(corpus)
+
+	return w.Error()
+}
+
+// readBatch turns each row of a -batch CSV file into the flags of a -run,
+// taking the flag names from the header and skipping empty cells.
+func readBatch(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf(msg("batch-unreadable"), path, err)
+	}
+
+	var runs []string
+
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return runs, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf(msg("batch-unreadable"), path, err)
+		}
+
+		var args []string
+		for k, v := range record {
+			if v = strings.TrimSpace(v); v != "" {
+				args = append(args, "--"+strings.TrimSpace(header[k])+"="+v)
+			}
+		}
+
+		runs = append(runs, strings.Join(args, " "))
+	}
+}
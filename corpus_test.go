@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+)
+
+// TestSeedCorpus reads the dumped corpus back as CSV, runs it as a batch
+// and checks every row's JSON result for a non-finite figure.
+func TestSeedCorpus(t *testing.T) {
+	out, _, _ := runArgs(t, "--seed-corpus")
+
+	records, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+	if err != nil || len(records) != len(corpus)+1 || strings.Join(records[0], ",") != "type,principal,periods,interest" {
+		t.Fatalf("%v in\n%s", err, out)
+	}
+
+	batch, errOut, code := runArgs(t, "--batch="+writeFile(t, "corpus.csv", out))
+	if code != 0 || errOut != "" || strings.Contains(batch, "Incorrect parameters") {
+		t.Errorf("the batch exits %d:\n%s%s", code, batch, errOut)
+	}
+
+	for _, record := range records[1:] {
+		var args []string
+		for k, v := range record {
+			args = append(args, "--"+records[0][k]+"="+v)
+		}
+
+		out, _, _ := runArgv(t, append(args, "--format=json")...)
+
+		var r Result
+		if err := json.Unmarshal([]byte(out), &r); err != nil || len(r.Schedule) == 0 {
+			t.Errorf("%v: %v in %.200s", args, err, out)
+			continue
+		}
+
+		figures := []float64{r.Payment.Float64(), r.Overpayment.Float64(), r.Principal.Float64()}
+		for _, row := range r.Schedule {
+			figures = append(figures, row.Payment.Float64(), row.InterestPortion.Float64(), row.Balance.Float64())
+		}
+		for _, f := range figures {
+			if math.IsNaN(f) || math.IsInf(f, 0) {
+				t.Errorf("%v: a figure of %g", args, f)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,58 @@
+//go:build cshared
+
+// Built with -tags cshared -buildmode=c-shared, the calculator is a shared
+// library exporting the annuity formulas to C. Each function returns 0 on
+// success and 1 for incorrect parameters, with the result stored through
+// the last argument.
+package main
+
+import "C"
+
+// resetFlags restores the defaults a previous call may have changed, as
+// monthly payments compounded monthly.
+func resetFlags() {
+	newFlagSet()
+	compoundingPerYear, paymentsPerYear = 12, 12
+}
+
+//export AnnuityPayment
+func AnnuityPayment(p, rate C.double, n C.int, out *C.double) C.int {
+	resetFlags()
+	principal, interest, periods = float64(p), float64(rate), int(n)
+
+	if principal <= 0 || interest < 0 || periods <= 0 {
+		return 1
+	}
+
+	*out = C.double(calculatePayment())
+
+	return 0
+}
+
+//export AnnuityPrincipal
+func AnnuityPrincipal(a, rate C.double, n C.int, out *C.double) C.int {
+	resetFlags()
+	payment, interest, periods = float64(a), float64(rate), int(n)
+
+	if payment <= 0 || interest < 0 || periods <= 0 {
+		return 1
+	}
+
+	*out = C.double(calculatePrincipal())
+
+	return 0
+}
+
+//export AnnuityPeriods
+func AnnuityPeriods(p, a, rate C.double, out *C.int) C.int {
+	resetFlags()
+	principal, payment, interest = float64(p), float64(a), float64(rate)
+
+	if principal <= 0 || interest < 0 || !paymentCoversInterest() {
+		return 1
+	}
+
+	*out = C.int(calculatePeriod())
+
+	return 0
+}
@@ -0,0 +1,53 @@
+//go:build cshared
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestCShared builds the shared library and calls it from the C program in
+// testdata/cshared, which needs a C compiler.
+func TestCShared(t *testing.T) {
+	cc, err := exec.LookPath("cc")
+	if err != nil {
+		t.Skip("no C compiler")
+	}
+
+	var sources []string
+	files, _ := filepath.Glob("*.go")
+	for _, f := range files {
+		if !strings.HasSuffix(f, "_test.go") {
+			sources = append(sources, f)
+		}
+	}
+
+	dir := t.TempDir()
+	build := exec.Command("go", append([]string{"build", "-tags", "cshared", "-buildmode=c-shared", "-o", filepath.Join(dir, "libloan.so")}, sources...)...)
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("%v: %s", err, out)
+	}
+
+	example, _ := filepath.Abs(filepath.Join("testdata", "cshared", "example.c"))
+	compile := exec.Command(cc, "-o", filepath.Join(dir, "example"), example, "-I", dir, "-L", dir, "-lloan")
+	if out, err := compile.CombinedOutput(); err != nil {
+		t.Fatalf("%v: %s", err, out)
+	}
+
+	run := exec.Command(filepath.Join(dir, "example"))
+	run.Env = append(os.Environ(), "LD_LIBRARY_PATH="+dir)
+	out, err := run.CombinedOutput()
+	if err != nil {
+		t.Fatalf("%v: %s", err, out)
+	}
+
+	// as the calculator prints them in the annuity examples
+	want := "payment 21248\nprincipal 800018\nperiods 24\nshort payment 1\nnegative principal 1\n"
+	if string(out) != want {
+		t.Errorf("got\n%s\nwant\n%s", out, want)
+	}
+}
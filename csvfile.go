@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// readRecords hands each row of the CSV file at path, of the given number
+// of fields, to parse, reporting errors by the "-row" and "-empty" messages
+// of kind. The first row is skipped as a header only when none
+// of its numeric columns holds a number, so that a malformed first row of
+// data is reported like any other.
+func readRecords(path, kind string, fields int, numeric []int, parse func(record []string) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = fields
+	r.TrimLeadingSpace = true
+
+	rows := 0
+	for row := 1; ; row++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if row == 1 && isHeader(record, numeric) {
+			continue
+		}
+
+		if err := parse(record); err != nil {
+			return fmt.Errorf(msg(kind+"-row"), row, err)
+		}
+		rows++
+	}
+
+	if rows == 0 {
+		return fmt.Errorf(msg(kind+"-empty"), path)
+	}
+
+	return nil
+}
+
+func isHeader(record []string, numeric []int) bool {
+	for _, k := range numeric {
+		if _, err := parseFinite(strings.TrimSpace(record[k])); err == nil {
+			return false
+		}
+	}
+
+	return true
+}
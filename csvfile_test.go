@@ -0,0 +1,48 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFile writes a file for the test, returning its path.
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestReadRecordsHeader(t *testing.T) {
+	for _, c := range []struct {
+		content string
+		rows    int
+		err     string
+	}{
+		{"lender,rate,term,fees\nA,5,12,0\nB,6,12,0\n", 2, ""},
+		{"A,5,12,0\nB,6,12,0\n", 2, ""},
+		{"A,abc,12,0\nB,6,12,0\n", 0, "offers row 1"},
+		{"A,5,12,-1\nB,6,12,0\n", 0, "offers row 1"},
+		{"lender,rate,term,fees\nA,5,x,0\n", 0, "offers row 2"},
+		{"lender,rate,term,fees\n", 0, "no offers"},
+	} {
+		offers, err := readOffers(writeFile(t, "offers.csv", c.content))
+		if c.err == "" && (err != nil || len(offers) != c.rows) {
+			t.Errorf("%q: %d offers, %v", c.content, len(offers), err)
+		}
+		if c.err != "" && (err == nil || !strings.Contains(err.Error(), c.err)) {
+			t.Errorf("%q: %v, want %q", c.content, err, c.err)
+		}
+	}
+
+	if _, err := readLoans(writeFile(t, "loans.csv", "car,x,5,12\nhome,1000,4,12\n")); err == nil ||
+		!strings.Contains(err.Error(), "loans row 1") {
+		t.Errorf("a malformed first loan was skipped: %v", err)
+	}
+}
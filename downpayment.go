@@ -0,0 +1,31 @@
+package main
+
+import "fmt"
+
+// doDownPaymentCalculations finds the principal whose payment is
+// -target-payment and the down payment on -price that leaves it.
+func doDownPaymentCalculations() error {
+	if !isProvided("price", "periods", "interest") {
+		return underSpecified("price", "periods", "interest")
+	}
+
+	if periods <= 0 {
+		return outOfRange("periods")
+	}
+
+	if targetPayment <= 0 {
+		return outOfRange("target-payment")
+	}
+
+	payment = targetPayment
+	principal = getAmortizer().annuityPrincipal()
+
+	down := moneyOf(price).Sub(moneyOf(principal))
+	if down < 0 {
+		return outOfRange("target-payment")
+	}
+
+	fmt.Fprintf(stdout, msg("down-payment"), moneyOf(payment), moneyOf(principal), moneyOf(price), down)
+
+	return nil
+}
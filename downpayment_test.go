@@ -0,0 +1,31 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDownPayment(t *testing.T) {
+	for _, c := range []struct {
+		args string
+		want string
+	}{
+		{"--price=300000 --target-payment=1500 --periods=360 --interest=6",
+			"A payment of 1500 pays off a principal of 250187, so the down payment on 300000 is 49813\n"},
+		{"--price=100000 --target-payment=500 --periods=120 --interest=0",
+			"A payment of 500 pays off a principal of 60000, so the down payment on 100000 is 40000\n"},
+		// the payment pays off more than the price, so no down payment reaches it
+		{"--price=300000 --target-payment=3000 --periods=360 --interest=6", "Incorrect parameters\n"},
+		{"--price=300000 --target-payment=0 --periods=360 --interest=6", "Incorrect parameters\n"},
+	} {
+		if out, _, _ := runArgs(t, c.args); out != c.want {
+			t.Errorf("%s: %q, want %q", c.args, out, c.want)
+		}
+	}
+
+	// the principal left after the down payment has the target payment
+	parseFlags(t, "--type=annuity --round=none --principal=250187 --periods=360 --interest=6")
+	if p := getAmortizer().annuityPayment(); math.Abs(p-1500) >= 0.01 {
+		t.Errorf("the remaining principal pays %g", p)
+	}
+}
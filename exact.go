@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// exactAmortizer mirrors floatAmortizer using math/big.Rat, so rounded
+// figures never drift across an integer boundary.
+type exactAmortizer struct{}
+
+func (exactAmortizer) annuityPrincipal() float64 {
+	i := exactInterestRate()
+	if i.Sign() == 0 {
+		return ratToFloat(exactRoundDown(ratMul(exactValue(payment), ratInt(int64(periods)))))
+	}
+
+	ni := ratPow(ratAdd(ratInt(1), i), periods)
+
+	// p = payment * (ni - 1) / (i * ni)
+	p := ratQuo(ratMul(exactValue(payment), ratSub(ni, ratInt(1))), ratMul(i, ni))
+
+	return ratToFloat(exactRoundDown(p))
+}
+
+func (exactAmortizer) annuityPayment() float64 {
+	i := exactInterestRate()
+	if i.Sign() == 0 {
+		return ratToFloat(exactRoundPayment(ratQuo(ratSub(exactValue(principal), exactValue(residual)), ratInt(int64(periods)))))
+	}
+
+	ni := ratPow(ratAdd(ratInt(1), i), periods)
+
+	// a = (principal * ni - residual) * i / (ni - 1)
+	a := ratQuo(ratMul(ratSub(ratMul(exactValue(principal), ni), exactValue(residual)), i), ratSub(ni, ratInt(1)))
+
+	return ratToFloat(exactRoundPayment(a))
+}
+
+func (exactAmortizer) diffPayments() []float64 {
+	var payments = make([]float64, 0, scheduleCapacity())
+	if periods == 0 {
+		return payments
+	}
+
+	p := exactValue(principal)
+	pn := ratQuo(p, ratInt(int64(periods)))
+	i := exactInterestRate()
+
+	for m := 1; m <= periods; m++ {
+		balance := ratSub(p, ratMul(pn, ratInt(int64(m-1))))
+		if diffFixedInterest {
+			balance = p
+		}
+		dp := ratAdd(pn, ratMul(i, balance))
+
+		payments = append(payments, ratToFloat(exactRoundByPolicy(dp, exactRoundUp)))
+	}
+
+	return payments
+}
+
+func (exactAmortizer) annuitySchedule(amount Money) []ScheduleRow {
+	i := exactInterestRate()
+
+	return buildAnnuitySchedule(amount, func(balance Money) Money {
+		return Money(ratRoundHalfAway(ratMul(ratInt(int64(balance)), i)).Num().Int64())
+	})
+}
+
+// exactInterestRate is the rational EffectivePeriodicRate.
+func exactInterestRate() *big.Rat {
+	r := ratQuo(ratSub(exactValue(interest), exactValue(interestSubsidy)), ratInt(int64(compoundingPerYear)*100))
+	if compoundingPerYear == paymentsPerYear {
+		return r
+	}
+
+	// (1 + r)^(c/p) is an irrational root, taken to ratRootPrec bits
+	g := new(big.Int).GCD(nil, nil, big.NewInt(int64(compoundingPerYear)), big.NewInt(int64(paymentsPerYear)))
+	c, p := compoundingPerYear/int(g.Int64()), paymentsPerYear/int(g.Int64())
+
+	return ratSub(ratRoot(ratPow(ratAdd(ratInt(1), r), c), p), ratInt(1))
+}
+
+// ratRootPrec is the precision, in bits, of the roots taken by ratRoot,
+// far beyond the cent of any amount the rate is applied to.
+const ratRootPrec = 256
+
+// ratRoot approximates the positive n-th root of y by Newton's method.
+func ratRoot(y *big.Rat, n int) *big.Rat {
+	if n == 1 {
+		return y
+	}
+
+	fy := new(big.Float).SetPrec(ratRootPrec).SetRat(y)
+	yf, _ := fy.Float64()
+	x := new(big.Float).SetPrec(ratRootPrec).SetFloat64(math.Pow(yf, 1/float64(n)))
+	bn := new(big.Float).SetPrec(ratRootPrec).SetInt64(int64(n))
+
+	for k := 0; k < 100; k++ {
+		// x -= (x^n - y) / (n x^(n-1))
+		xn1 := new(big.Float).SetPrec(ratRootPrec).SetInt64(1)
+		for j := 1; j < n; j++ {
+			xn1.Mul(xn1, x)
+		}
+
+		step := new(big.Float).SetPrec(ratRootPrec).Mul(xn1, x)
+		step.Sub(step, fy)
+		step.Quo(step, new(big.Float).SetPrec(ratRootPrec).Mul(bn, xn1))
+
+		if step.Sign() == 0 || step.MantExp(nil)-x.MantExp(nil) < -ratRootPrec {
+			break
+		}
+		x.Sub(x, step)
+	}
+
+	r, _ := x.Rat(nil)
+
+	return r
+}
+
+// exactValue takes a flag value by its shortest decimal form, so that e.g.
+// 5.6 becomes 56/10 rather than the nearest binary fraction. The flags only
+// take finite numbers, so failing to convert one is a bug.
+func exactValue(v float64) *big.Rat {
+	r, ok := new(big.Rat).SetString(strconv.FormatFloat(v, 'f', -1, 64))
+	if !ok {
+		panic(fmt.Sprintf("exact arithmetic on the non-finite value %v", v))
+	}
+
+	return r
+}
+
+func ratInt(v int64) *big.Rat        { return new(big.Rat).SetInt64(v) }
+func ratAdd(a, b *big.Rat) *big.Rat  { return new(big.Rat).Add(a, b) }
+func ratSub(a, b *big.Rat) *big.Rat  { return new(big.Rat).Sub(a, b) }
+func ratMul(a, b *big.Rat) *big.Rat  { return new(big.Rat).Mul(a, b) }
+func ratQuo(a, b *big.Rat) *big.Rat  { return new(big.Rat).Quo(a, b) }
+func ratToFloat(r *big.Rat) float64  { f, _ := r.Float64(); return f }
+func ratFromInt(v *big.Int) *big.Rat { return new(big.Rat).SetInt(v) }
+func ratFloor(r *big.Rat) *big.Rat   { return ratFromInt(new(big.Int).Div(r.Num(), r.Denom())) }
+func ratCeil(r *big.Rat) *big.Rat    { return ratSub(ratInt(0), ratFloor(ratSub(ratInt(0), r))) }
+
+// ratRoundHalfAway rounds to an integer with halves away from zero, as
+// math.Round does.
+func ratRoundHalfAway(r *big.Rat) *big.Rat {
+	half := big.NewRat(1, 2)
+	if r.Sign() < 0 {
+		return ratCeil(ratSub(r, half))
+	}
+
+	return ratFloor(ratAdd(r, half))
+}
+
+func exactRoundUp(r *big.Rat) *big.Rat {
+	if displayRounding || roundPolicy == "none" {
+		return r
+	}
+
+	u := exactUnit()
+
+	return ratMul(ratCeil(ratQuo(r, u)), u)
+}
+
+func exactRoundDown(r *big.Rat) *big.Rat {
+	if displayRounding || roundPolicy == "none" {
+		return r
+	}
+
+	u := exactUnit()
+
+	return ratMul(ratFloor(ratQuo(r, u)), u)
+}
+
+func exactRoundPayment(r *big.Rat) *big.Rat {
+	if favor == "borrower" {
+		return exactRoundByPolicy(r, exactRoundDown)
+	}
+
+	return exactRoundByPolicy(r, exactRoundUp)
+}
+
+func exactUnit() *big.Rat {
+	return ratQuo(ratInt(int64(moneyUnit())), ratInt(100))
+}
+
+// ratPow raises r to the power n >= 0 by repeated squaring.
+func ratPow(r *big.Rat, n int) *big.Rat {
+	res := ratInt(1)
+	for ; n > 0; n >>= 1 {
+		if n&1 == 1 {
+			res = ratMul(res, r)
+		}
+		r = ratMul(r, r)
+	}
+
+	return res
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+// referencePayment computes the unrounded annuity payment in 256-bit
+// floats, independently of both amortizers.
+func referencePayment(p, rate float64, n int) float64 {
+	const prec = 256
+
+	i := new(big.Float).SetPrec(prec).Quo(big.NewFloat(rate), big.NewFloat(1200))
+	base := new(big.Float).SetPrec(prec).Add(big.NewFloat(1), i)
+
+	ni := new(big.Float).SetPrec(prec).SetInt64(1)
+	for k := 0; k < n; k++ {
+		ni.Mul(ni, base)
+	}
+
+	a := new(big.Float).SetPrec(prec).Mul(big.NewFloat(p), i)
+	a.Mul(a, ni)
+	a.Quo(a, new(big.Float).SetPrec(prec).Sub(ni, big.NewFloat(1)))
+
+	f, _ := a.Float64()
+
+	return f
+}
+
+// TestLargePrincipalStability covers the range floatExactLimit documents:
+// below it the float64 payment is within a cent of the reference, and from
+// it on getAmortizer switches to the exact arithmetic, which stays there up
+// to the largest amount getAction accepts.
+func TestLargePrincipalStability(t *testing.T) {
+	principals := []float64{1e6, 1e9, 1e11, 9.99e11, 1e12, 1e13, 9e13}
+	rates := []float64{0.5, 3.14159, 7.375, 35}
+	terms := []int{1, 12, 360, 1200}
+
+	for _, p := range principals {
+		for _, rate := range rates {
+			for _, n := range terms {
+				args := fmt.Sprintf("--type=annuity --round=none --principal=%f --interest=%g --periods=%d", p, rate, n)
+				parseFlags(t, args)
+
+				got := getAmortizer().annuityPayment()
+				want := referencePayment(p, rate, n)
+
+				if math.Abs(got-want) >= 0.01 {
+					t.Errorf("%s: payment %f, reference %f", args, got, want)
+				}
+
+				if _, isExact := getAmortizer().(exactAmortizer); isExact != (p >= floatExactLimit) {
+					t.Errorf("%s: exact arithmetic is %v", args, isExact)
+				}
+			}
+		}
+	}
+}
+
+// TestExactScheduleDrift compares the float64 and rational schedules of a
+// loan whose float64 interest drifts, against its interest total worked
+// out independently with Python's fractions.
+func TestExactScheduleDrift(t *testing.T) {
+	parseFlags(t, "--type=annuity --principal=250000 --periods=300 --interest=5.25")
+
+	_, floatInterest := scheduleTotals(floatAmortizer{}.annuitySchedule(moneyOf(1499)))
+	_, exactInterest := scheduleTotals(exactAmortizer{}.annuitySchedule(moneyOf(1499)))
+
+	if want := Money(19915570); exactInterest != want {
+		t.Errorf("exact interest %s, want %s", exactInterest, want)
+	}
+	if floatInterest == exactInterest {
+		t.Errorf("float interest %s no longer drifts from the exact one", floatInterest)
+	}
+
+	out, _, _ := runArgs(t, "--type=annuity --principal=250000 --periods=300 --interest=5.25 --exact --explain")
+	if !strings.Contains(out, "Interest = 199155.70 (sum over 300 months)") {
+		t.Errorf("--exact --explain doesn't sum the rational schedule:\n%s", out)
+	}
+}
+
+// TestExactCompoundingRate checks the rational rate of compounding that
+// differs from the payments against the float64 one, and that
+// semiannual compounding is the sixth root taken for monthly payments.
+func TestExactCompoundingRate(t *testing.T) {
+	for _, args := range []string{
+		"--compounding=semiannual",
+		"--compounding=daily --payment-frequency=biweekly",
+		"--compounding=annual --payment-frequency=weekly",
+	} {
+		parseFlags(t, "--type=annuity --principal=1000 --periods=12 --interest=6 "+args)
+
+		got, _ := exactInterestRate().Float64()
+		if want := getInterestRate(); math.Abs(got-want) > 1e-15 {
+			t.Errorf("%s: exact rate %g, float64 rate %g", args, got, want)
+		}
+	}
+
+	parseFlags(t, "--type=annuity --principal=1000 --periods=12 --interest=6 --compounding=semiannual")
+
+	// (1 + i)^6 must come back to the semiannual 3%
+	sixth := ratPow(ratAdd(ratInt(1), exactInterestRate()), 6)
+	if diff, _ := ratSub(sixth, big.NewRat(103, 100)).Float64(); math.Abs(diff) > 1e-60 {
+		t.Errorf("(1 + i)^6 is off 1.03 by %g", diff)
+	}
+}
+
+// TestExactAmortizer checks the rational payments against the reference,
+// and against the float64 ones, which agree at these sizes for a rate
+// above zero.
+func TestExactAmortizer(t *testing.T) {
+	for _, c := range []struct {
+		principal, rate float64
+		periods         int
+	}{
+		{1000000, 10, 60},
+		{250000, 5.25, 300},
+		{123456, 3.5, 154},
+		{1000, 12, 7},
+		{1000, 0, 7},
+	} {
+		args := fmt.Sprintf("--type=annuity --principal=%g --periods=%d --interest=%g", c.principal, c.periods, c.rate)
+		parseFlags(t, args)
+
+		want := math.Ceil(c.principal / float64(c.periods))
+		if c.rate > 0 {
+			want = math.Ceil(referencePayment(c.principal, c.rate, c.periods))
+		}
+		if got := (exactAmortizer{}).annuityPayment(); got != want {
+			t.Errorf("%s: exact payment %g, want %g", args, got, want)
+		}
+
+		if c.rate == 0 {
+			continue
+		}
+		out, _, _ := runArgs(t, args)
+		if exactOut, _, _ := runArgs(t, args+" --exact"); exactOut != out {
+			t.Errorf("%s: --exact prints\n%s\ninstead of\n%s", args, exactOut, out)
+		}
+	}
+
+	parseFlags(t, "--type=diff --principal=1000000 --periods=10 --interest=10")
+	if got, want := fmt.Sprint(exactAmortizer{}.diffPayments()), fmt.Sprint(calculateDiffPayments()); got != want {
+		t.Errorf("exact diff payments %s, want %s", got, want)
+	}
+}
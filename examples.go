@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// examples are printed by -help-examples; each must run as it stands.
+var examples = []struct {
+	args        string
+	description string
+}{
+	{"--type=annuity --principal=1000000 --periods=60 --interest=10", "The annuity payment of a loan"},
+	{"--type=annuity --payment=8722 --periods=120 --interest=5.6", "The principal a payment pays off"},
+	{"--type=annuity --principal=500000 --payment=23000 --interest=7.8", "How long a payment takes to pay off a loan"},
+	{"--type=diff --principal=500000 --periods=8 --interest=7.8 --schedule", "Differentiated payments with their schedule"},
+	{"--principal=300000 --years=30 --interest=6 --compare-frequency", "Monthly against biweekly payments of a loan"},
+	{"--principal=1000000 --rate-sweep=8,9,10 --terms=36,60", "Payments by rate and term"},
+}
+
+func displayExamples() {
+	name := filepath.Base(os.Args[0])
+
+	for _, e := range examples {
+		fmt.Fprintf(stdout, "# %s\n%s %s\n\n", e.description, name, e.args)
+	}
+}
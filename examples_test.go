@@ -0,0 +1,26 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestExamples checks that every example parses into a calculation and
+// runs without an error, and that -help-examples prints it.
+func TestExamples(t *testing.T) {
+	listing, _, _ := runArgs(t, "--help-examples")
+
+	for _, e := range examples {
+		if action := parseFlags(t, e.args); action == CalcInvalid {
+			t.Errorf("%s: no calculation", e.args)
+		}
+
+		if out, errOut, code := runArgs(t, e.args); code != 0 || errOut != "" || strings.Contains(out, "Incorrect parameters") {
+			t.Errorf("%s: exit %d\n%s%s", e.args, code, out, errOut)
+		}
+
+		if !strings.Contains(listing, "# "+e.description+"\n") || !strings.Contains(listing, " "+e.args+"\n\n") {
+			t.Errorf("%s isn't listed in\n%s", e.args, listing)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+)
+
+type parseCheck struct {
+	label string
+	ok    bool
+}
+
+// parseReport collects what -explain-parse prints.
+var parseReport struct {
+	flags  []string
+	checks []parseCheck
+}
+
+func startParseReport(fs *flag.FlagSet) {
+	parseReport.flags, parseReport.checks = nil, nil
+	if !explainParse {
+		return
+	}
+
+	fs.Visit(func(f *flag.Flag) {
+		parseReport.flags = append(parseReport.flags, fmt.Sprintf("--%s=%s", f.Name, flagValue(f)))
+	})
+}
+
+// checked records a validation check for -explain-parse and returns its
+// outcome.
+func checked(label string, ok bool) bool {
+	if explainParse {
+		parseReport.checks = append(parseReport.checks, parseCheck{label, ok})
+	}
+
+	return ok
+}
+
+func displayParseReport(action CalcType, err error) {
+	fmt.Fprintln(stdout, msg("parse-flags"))
+	for _, f := range parseReport.flags {
+		fmt.Fprintf(stdout, "  %s\n", f)
+	}
+
+	fmt.Fprintln(stdout, msg("parse-checks"))
+	for _, c := range parseReport.checks {
+		status := msg("parse-pass")
+		if !c.ok {
+			status = msg("parse-fail")
+		}
+		fmt.Fprintf(stdout, "  %-4s  %s\n", status, c.label)
+	}
+
+	if err != nil {
+		fmt.Fprintf(stdout, msg("parse-error"), describeError(err))
+		fmt.Fprintln(stdout)
+		return
+	}
+
+	fmt.Fprintf(stdout, msg("parse-mode"), modeName(action))
+
+	if action == CalcAnnual {
+		// getAnnualAction only reads the flags, so it's safe to run ahead
+		target, err := getAnnualAction()
+		if err != nil {
+			fmt.Fprintf(stdout, msg("parse-target-none"), describeError(err))
+		} else {
+			fmt.Fprintf(stdout, msg("parse-target"), modeName(target))
+		}
+	}
+
+	fmt.Fprintln(stdout)
+}
+
+// describeError spells out the code and fields of a paramError.
+func describeError(err error) string {
+	var pe paramError
+	if errors.As(err, &pe) {
+		return fmt.Sprintf("%s %v", pe.code, pe.fields)
+	}
+
+	return err.Error()
+}
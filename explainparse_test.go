@@ -0,0 +1,63 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// report splits the -explain-parse report from the calculation after it.
+func report(t *testing.T, args string) string {
+	t.Helper()
+
+	out, _, _ := runArgs(t, args+" --explain-parse")
+	r, _, ok := strings.Cut(out, "\n\n")
+	if !ok {
+		t.Fatalf("%s: no report in\n%s", args, out)
+	}
+
+	return r + "\n"
+}
+
+func TestExplainParse(t *testing.T) {
+	for _, c := range []struct {
+		args, target string
+	}{
+		{"--principal=1000 --periods=12 --interest=5", "payment"},
+		{"--payment=100 --periods=12 --interest=5", "principal"},
+		{"--principal=1000 --payment=100 --interest=5", "period"},
+		{"--principal=1000 --payment=100 --periods=12", "interest"},
+		{"--principal=1000 --periods=12", "nothing (under-specified [payment interest])"},
+	} {
+		r := report(t, "--type=annuity "+c.args)
+
+		if !strings.HasSuffix(r, "Calculation: annuity\nSolving for: "+c.target+"\n") {
+			t.Errorf("%s: want the target %s in\n%s", c.args, c.target, r)
+		}
+		// each amount given is range checked, the options always
+		checks := []string{"  pass  -favor is lender or borrower\n", "  pass  -round is a rounding policy\n"}
+		for _, arg := range strings.Fields(c.args) {
+			name, _, _ := strings.Cut(strings.TrimPrefix(arg, "--"), "=")
+			checks = append(checks, "  pass  -"+name+" >= 0\n")
+		}
+		for _, check := range checks {
+			if !strings.Contains(r, check) {
+				t.Errorf("%s: no %q in\n%s", c.args, check, r)
+			}
+		}
+		if strings.Contains(r, "FAIL") {
+			t.Errorf("%s: a check failed in\n%s", c.args, r)
+		}
+	}
+
+	// every flag given is listed, and the first failing check ends it
+	r := report(t, "--type=annuity --principal=1000 --periods=12 --interest=-5")
+	want := "Flags given:\n  --explain-parse=true\n  --interest=-5\n  --periods=12\n  --principal=1000\n  --type=annuity\n" +
+		"Checks:\n  FAIL  -interest >= 0\nRejected: out-of-range [interest]\n"
+	if r != want {
+		t.Errorf("got\n%s\nwant\n%s", r, want)
+	}
+
+	if r := report(t, "--type=diff --principal=1000 --periods=12 --interest=5"); !strings.HasSuffix(r, "Calculation: diff\n") {
+		t.Errorf("diff:\n%s", r)
+	}
+}
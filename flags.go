@@ -0,0 +1,278 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseFinite parses a float, rejecting the infinities and NaN which
+// strconv accepts but no amount or rate can be.
+func parseFinite(s string) (float64, error) {
+	v, err := strconv.ParseFloat(s, 64)
+	if err == nil && (math.IsInf(v, 0) || math.IsNaN(v)) {
+		err = fmt.Errorf("%q is not a finite number", s)
+	}
+
+	return v, err
+}
+
+// floatValue is a flag.Value for a rate or other float, like the one of
+// flag.Float64Var but finite.
+type floatValue float64
+
+func newFloatValue(val float64, p *float64) *floatValue {
+	*p = val
+	return (*floatValue)(p)
+}
+
+func (fv *floatValue) String() string { return strconv.FormatFloat(float64(*fv), 'f', -1, 64) }
+
+func (fv *floatValue) Get() any { return float64(*fv) }
+
+func (fv *floatValue) Set(s string) error {
+	v, err := parseFinite(s)
+	if err != nil {
+		return fmt.Errorf("invalid number %q", s)
+	}
+
+	*fv = floatValue(v)
+
+	return nil
+}
+
+// amountValue is a flag.Value for an amount of money, which may be given
+// in thousands or millions as e.g. "500k" or "1.2m".
+type amountValue float64
+
+func newAmountValue(val float64, p *float64) *amountValue {
+	*p = val
+	return (*amountValue)(p)
+}
+
+func (a *amountValue) String() string { return strconv.FormatFloat(float64(*a), 'f', -1, 64) }
+
+func (a *amountValue) Get() any { return float64(*a) }
+
+func (a *amountValue) Set(s string) error {
+	multiplier := 1.0
+	switch {
+	case strings.HasSuffix(s, "k"), strings.HasSuffix(s, "K"):
+		multiplier = 1e3
+	case strings.HasSuffix(s, "m"), strings.HasSuffix(s, "M"):
+		multiplier = 1e6
+	}
+
+	number := s
+	if multiplier != 1 {
+		number = s[:len(s)-1]
+	}
+
+	v, err := parseFinite(number)
+	if err == nil && multiplier != 1 {
+		// to the cent, so that e.g. 1.2m isn't a binary fraction off
+		v = math.Round(v*multiplier*100) / 100
+	}
+
+	if err != nil || math.IsInf(v, 0) {
+		return fmt.Errorf("invalid amount %q, expected a number with an optional k or m suffix", s)
+	}
+
+	*a = amountValue(v)
+
+	return nil
+}
+
+type monthValue struct {
+	month int
+	value float64
+}
+
+// monthValues is a flag.Value holding a "month:value,..." list, kept
+// sorted by month.
+type monthValues []monthValue
+
+func (mv *monthValues) String() string {
+	var parts = make([]string, 0, len(*mv))
+
+	for _, v := range *mv {
+		parts = append(parts, fmt.Sprintf("%d:%g", v.month, v.value))
+	}
+
+	return strings.Join(parts, ",")
+}
+
+func (mv *monthValues) Set(s string) error {
+	var values monthValues
+
+	for _, part := range strings.Split(s, ",") {
+		m, v, ok := strings.Cut(strings.TrimSpace(part), ":")
+		if !ok {
+			return fmt.Errorf("expected month:value, got %q", part)
+		}
+
+		month, err := strconv.Atoi(m)
+		if err != nil || month < 1 {
+			return fmt.Errorf("invalid month %q", m)
+		}
+
+		value, err := parseFinite(v)
+		if err != nil {
+			return fmt.Errorf("invalid value %q", v)
+		}
+
+		values = append(values, monthValue{month, value})
+	}
+
+	sort.SliceStable(values, func(a, b int) bool { return values[a].month < values[b].month })
+	*mv = values
+
+	return nil
+}
+
+// monthSet is a flag.Value holding a "month,..." list.
+type monthSet map[int]bool
+
+func (ms *monthSet) String() string {
+	var months = make([]int, 0, len(*ms))
+
+	for m := range *ms {
+		months = append(months, m)
+	}
+	sort.Ints(months)
+
+	var parts = make([]string, 0, len(months))
+
+	for _, m := range months {
+		parts = append(parts, strconv.Itoa(m))
+	}
+
+	return strings.Join(parts, ",")
+}
+
+func (ms *monthSet) Set(s string) error {
+	var months = make(monthSet)
+
+	for _, part := range strings.Split(s, ",") {
+		month, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || month < 1 {
+			return fmt.Errorf("invalid month %q", part)
+		}
+
+		months[month] = true
+	}
+
+	*ms = months
+
+	return nil
+}
+
+// now is the clock the date defaults are taken from, a variable so that
+// "today" can be pinned.
+var now = time.Now
+
+func today() time.Time {
+	y, m, d := now().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// dateValue is a flag.Value holding a YYYY-MM-DD date.
+type dateValue struct {
+	time.Time
+}
+
+func (dv *dateValue) String() string {
+	if dv.IsZero() {
+		return ""
+	}
+
+	return dv.Format(time.DateOnly)
+}
+
+func (dv *dateValue) Set(s string) error {
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return fmt.Errorf("expected YYYY-MM-DD, got %q", s)
+	}
+
+	dv.Time = t
+
+	return nil
+}
+
+// stringList is a flag.Value collecting every use of a repeatable flag.
+type stringList []string
+
+func (sl *stringList) String() string {
+	return strings.Join(*sl, "; ")
+}
+
+func (sl *stringList) Set(s string) error {
+	*sl = append(*sl, s)
+
+	return nil
+}
+
+// floatList is a flag.Value holding a "value,..." list in the given order.
+type floatList []float64
+
+func (fl *floatList) String() string {
+	var parts = make([]string, 0, len(*fl))
+
+	for _, v := range *fl {
+		parts = append(parts, strconv.FormatFloat(v, 'f', -1, 64))
+	}
+
+	return strings.Join(parts, ",")
+}
+
+func (fl *floatList) Set(s string) error {
+	var values floatList
+
+	for _, part := range strings.Split(s, ",") {
+		v, err := parseFinite(strings.TrimSpace(part))
+		if err != nil || v < 0 {
+			return fmt.Errorf("invalid value %q", part)
+		}
+
+		values = append(values, v)
+	}
+
+	*fl = values
+
+	return nil
+}
+
+// intList is a flag.Value holding a "count,..." list of positive counts in
+// the given order.
+type intList []int
+
+func (il *intList) String() string {
+	var parts = make([]string, 0, len(*il))
+
+	for _, v := range *il {
+		parts = append(parts, strconv.Itoa(v))
+	}
+
+	return strings.Join(parts, ",")
+}
+
+func (il *intList) Set(s string) error {
+	var values intList
+
+	for _, part := range strings.Split(s, ",") {
+		v, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || v < 1 {
+			return fmt.Errorf("invalid count %q", part)
+		}
+
+		values = append(values, v)
+	}
+
+	*il = values
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestDefaultStartDate pins the clock late on New Year's Eve west of
+// Greenwich, where it's already the next year in UTC: the loan starts on
+// the local date, and its first payment a month later falls in 2032.
+func TestDefaultStartDate(t *testing.T) {
+	saved := now
+	defer func() { now = saved }()
+	now = func() time.Time {
+		return time.Date(2031, time.December, 31, 22, 0, 0, 0, time.FixedZone("EST", -5*3600))
+	}
+
+	var out bytes.Buffer
+	run(strings.Fields("--type=annuity --principal=1000 --periods=12 --interest=5 --total-interest-breakdown-by-year"), &out, io.Discard)
+
+	if rows := yearRows(out.String()); fmt.Sprint(rows) != "[[2032 27.19] [Total 27.19]]" {
+		t.Errorf("starting on 2031-12-31: %v", rows)
+	}
+
+	if today := today(); today != time.Date(2031, time.December, 31, 0, 0, 0, 0, time.UTC) {
+		t.Errorf("today is %v", today)
+	}
+}
+
+func TestAmountSuffixes(t *testing.T) {
+	for _, c := range []struct {
+		in   string
+		want float64
+	}{
+		{"1m", 1e6},
+		{"500k", 500000},
+		{"1.5m", 1500000},
+		{"1.2M", 1200000},
+		{"2.5K", 2500},
+		{"123456.78", 123456.78},
+	} {
+		var v float64
+		if err := newAmountValue(-1, &v).Set(c.in); err != nil || v != c.want {
+			t.Errorf("%s: %v, %v; want %v", c.in, v, err, c.want)
+		}
+	}
+
+	for _, in := range []string{"1x", "k", "1mm", "1.2.3k", ""} {
+		var v float64
+		if err := newAmountValue(-1, &v).Set(in); err == nil {
+			t.Errorf("%q was accepted as %v", in, v)
+		}
+	}
+}
+
+// TestAmountsReproduced checks that suffixed and large amounts are echoed
+// in full, without an exponent.
+func TestAmountsReproduced(t *testing.T) {
+	out, _, _ := runArgs(t, "--type=annuity --principal=1.2m --periods=60 --interest=10 --reproduce")
+	if !strings.Contains(out, "--principal=1200000 ") {
+		t.Errorf("the command doesn't spell out the principal:\n%s", out)
+	}
+
+	out, _, _ = runArgs(t, "--type=annuity --principal=1000000 --periods=60 --interest=10 --explain-parse")
+	if strings.Contains(out, "e+") {
+		t.Errorf("the parse report has an exponent:\n%s", out)
+	}
+}
+
+func TestNonFiniteFlagsRejected(t *testing.T) {
+	for _, args := range [][]string{
+		{"--principal=inf"},
+		{"--payment=-Inf"},
+		{"--principal=NaN"},
+		{"--principal=1e308m"},
+		{"--interest=inf"},
+		{"--new-interest=NaN"},
+		{"--rate-sweep=5,inf"},
+		{"--recast=12:NaN"},
+	} {
+		fs := newFlagSet()
+		fs.SetOutput(io.Discard)
+		if err := fs.Parse(args); err == nil {
+			t.Errorf("%v was accepted", args)
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// formatter writes a Result in one of the --format output formats.
+type formatter func(w io.Writer, r Result) error
+
+// formatters lists the --format values besides "text", the built-in
+// prose output.
+var formatters = map[string]formatter{
+	"env":      formatEnv,
+	"json":     formatJSON,
+	"csv":      formatCSV,
+	"markdown": formatMarkdown,
+}
+
+func validFormat(name string) bool {
+	_, ok := formatters[name]
+	return ok || name == "text"
+}
+
+// outputTarget is one of the comma-separated -format values, written to
+// path, or to stdout without one.
+type outputTarget struct {
+	format, path string
+}
+
+// stdoutFormat and fileOutputs are resolved from -format by run.
+var (
+	stdoutFormat string
+	fileOutputs  []outputTarget
+)
+
+// parseFormats splits -format into the one format written to stdout,
+// "text" unless given, and those written to files. Text output can't go
+// to a file, being printed as it's computed.
+func parseFormats(s string) (string, []outputTarget, bool) {
+	var files []outputTarget
+	var primary string
+
+	for _, part := range strings.Split(s, ",") {
+		name, path, toFile := strings.Cut(part, ":")
+		if !validFormat(name) {
+			return "", nil, false
+		}
+
+		switch {
+		case toFile && name != "text" && path != "":
+			files = append(files, outputTarget{name, path})
+		case toFile || primary != "":
+			return "", nil, false
+		default:
+			primary = name
+		}
+	}
+
+	if primary == "" {
+		primary = "text"
+	}
+
+	return primary, files, true
+}
+
+// scheduleFormatters write only the schedule of a Result for
+// --schedule-only, by --format.
+var scheduleFormatters = map[string]formatter{
+	"text":     formatScheduleText,
+	"json":     formatScheduleJSON,
+	"csv":      formatCSV,
+	"markdown": formatMarkdown,
+}
+
+// scheduleFormats reports whether every -format target has a schedule
+// formatter, as -schedule-only needs.
+func scheduleFormats() bool {
+	if _, ok := scheduleFormatters[stdoutFormat]; !ok {
+		return false
+	}
+
+	for _, t := range fileOutputs {
+		if _, ok := scheduleFormatters[t.format]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// getFormatter returns nil when the built-in prose output should be used.
+func getFormatter() formatter {
+	if scheduleOnly {
+		return scheduleFormatters[stdoutFormat]
+	}
+
+	if query != "" {
+		return formatQuery
+	}
+
+	if outputTemplate.tmpl != nil {
+		return outputTemplate.render
+	}
+
+	return formatters[stdoutFormat]
+}
+
+// emitResult writes the result to the -format files and, unless the
+// built-in prose output should be used, stdout, which it reports.
+func emitResult(r Result) (bool, error) {
+	for _, t := range fileOutputs {
+		f := formatters[t.format]
+		if scheduleOnly {
+			f = scheduleFormatters[t.format]
+		}
+
+		if err := writeOutputFile(t.path, f, r); err != nil {
+			return true, err
+		}
+	}
+
+	f := getFormatter()
+	if f == nil {
+		return false, nil
+	}
+
+	return true, f(stdout, r)
+}
+
+func writeOutputFile(path string, f formatter, r Result) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if err := f(out, r); err != nil {
+		return err
+	}
+
+	return out.Close()
+}
+
+// formatEnv prints shell assignments suitable for eval.
+func formatEnv(w io.Writer, r Result) error {
+	vars := [][2]string{
+		{"LOAN_PAYMENT", r.Payment.String()},
+		{"LOAN_PRINCIPAL", r.Principal.String()},
+		{"LOAN_PERIODS", strconv.Itoa(r.Periods)},
+		{"LOAN_INTEREST", strconv.FormatFloat(r.Interest, 'f', -1, 64)},
+		{"LOAN_OVERPAYMENT", r.Overpayment.String()},
+	}
+
+	if r.ScheduledOverpayment != nil {
+		vars = append(vars, [2]string{"LOAN_SCHEDULED_OVERPAYMENT", r.ScheduledOverpayment.String()})
+	}
+
+	for _, v := range vars {
+		if _, err := fmt.Fprintf(w, "%s=%s\n", v[0], shellQuote(v[1])); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// jsonEncoder indents the JSON output under -pretty.
+func jsonEncoder(w io.Writer) *json.Encoder {
+	enc := json.NewEncoder(w)
+	if pretty {
+		enc.SetIndent("", "  ")
+	}
+
+	return enc
+}
+
+func formatJSON(w io.Writer, r Result) error {
+	return jsonEncoder(w).Encode(r)
+}
+
+func formatScheduleText(w io.Writer, r Result) error {
+	return writeSchedule(w, r.Schedule)
+}
+
+func formatScheduleJSON(w io.Writer, r Result) error {
+	return jsonEncoder(w).Encode(struct {
+		SchemaVersion int           `json:"schema_version"`
+		Schedule      []ScheduleRow `json:"schedule"`
+	}{r.SchemaVersion, r.Schedule})
+}
+
+// formatCSV writes the schedule, one row per month.
+func formatCSV(w io.Writer, r Result) error {
+	cw := csv.NewWriter(w)
+
+	cw.Write([]string{"month", "payment", "interest_portion", "principal_portion", "balance",
+		"cumulative_interest", "cumulative_principal"})
+
+	for _, row := range r.Schedule {
+		cw.Write([]string{strconv.Itoa(row.Month), row.Payment.String(), row.InterestPortion.String(),
+			row.PrincipalPortion.String(), row.Balance.String(),
+			row.CumulativeInterest.String(), row.CumulativePrincipal.String()})
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
+
+// formatMarkdown writes the schedule as a GitHub-flavored Markdown table,
+// padding the right-aligned columns to the same width.
+func formatMarkdown(w io.Writer, r Result) error {
+	header := strings.Split(strings.TrimSuffix(msg("schedule-header"), "\t"), "\t")
+	rows := [][]string{header}
+
+	for _, row := range r.Schedule {
+		rows = append(rows, []string{strconv.Itoa(row.Month), row.Payment.String(),
+			row.InterestPortion.String(), row.PrincipalPortion.String(), row.Balance.String()})
+	}
+
+	widths := make([]int, len(header))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], len(cell), 3)
+		}
+	}
+
+	separator := make([]string, len(header))
+	for i, width := range widths {
+		separator[i] = strings.Repeat("-", width-1) + ":"
+	}
+	rows = append(rows[:1], append([][]string{separator}, rows[1:]...)...)
+
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = fmt.Sprintf("%*s", widths[i], cell)
+		}
+
+		if _, err := fmt.Fprintf(w, "| %s |\n", strings.Join(cells, " | ")); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestFormatEnv parses the env output the way a shell eval would and
+// checks that every value is a number.
+func TestFormatEnv(t *testing.T) {
+	out, _, _ := runArgs(t, "--type=annuity --principal=1000 --periods=12 --interest=12 --format=env")
+
+	vars := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\n"), "\n") {
+		key, value, ok := strings.Cut(line, "=")
+		unquoted := strings.TrimSuffix(strings.TrimPrefix(value, "'"), "'")
+		if !ok || value != "'"+unquoted+"'" || strings.Contains(unquoted, "'") {
+			t.Fatalf("not a quoted assignment: %q", line)
+		}
+
+		if _, err := strconv.ParseFloat(unquoted, 64); err != nil {
+			t.Errorf("%s is not a number: %v", key, err)
+		}
+		vars[key] = unquoted
+	}
+
+	want := map[string]string{
+		"LOAN_PAYMENT":               "89",
+		"LOAN_PRINCIPAL":             "1000",
+		"LOAN_PERIODS":               "12",
+		"LOAN_INTEREST":              "12",
+		"LOAN_OVERPAYMENT":           "68",
+		"LOAN_SCHEDULED_OVERPAYMENT": "66.07",
+	}
+	if len(vars) != len(want) {
+		t.Errorf("keys %v", vars)
+	}
+	for key, value := range want {
+		if vars[key] != value {
+			t.Errorf("%s=%q, want %q", key, vars[key], value)
+		}
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	for s, want := range map[string]string{
+		"":      "''",
+		"1.5":   "'1.5'",
+		"it's":  `'it'\''s'`,
+		"$(rm)": "'$(rm)'",
+	} {
+		if got := shellQuote(s); got != want {
+			t.Errorf("shellQuote(%q) = %s, want %s", s, got, want)
+		}
+	}
+}
+
+// TestScheduleOnly checks that --schedule-only prints the schedule alone,
+// without the summary lines, in every schedule format.
+func TestScheduleOnly(t *testing.T) {
+	for _, loan := range []string{
+		"--type=annuity --principal=1000 --periods=3 --interest=12",
+		"--type=diff --principal=1000 --periods=3 --interest=12",
+		"--type=annuity --principal=1000 --payment=341 --interest=12",
+	} {
+		for _, format := range []string{"text", "csv", "markdown"} {
+			out, _, _ := runArgs(t, loan+" --schedule-only --format="+format)
+
+			lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+			if format == "markdown" {
+				lines = append(lines[:1], lines[2:]...)
+			}
+			if len(lines) != 4 {
+				t.Errorf("%s --format=%s: %d lines:\n%s", loan, format, len(lines), out)
+			}
+
+			for _, prose := range []string{"Overpayment", "Your", "It will take", "Final payment", "Month 1:"} {
+				if strings.Contains(out, prose) {
+					t.Errorf("%s --format=%s: summary %q:\n%s", loan, format, prose, out)
+				}
+			}
+		}
+	}
+}
+
+// TestMarkdown checks the header and its separator, a row per month and
+// columns padded to the same width, in units and in cents.
+func TestMarkdown(t *testing.T) {
+	for _, c := range []struct {
+		args, first string
+		rows        int
+	}{
+		{"--type=annuity --principal=1000 --periods=3 --interest=12", "|     1 |     341 |       10 |       331 |     669 |", 3},
+		{"--type=annuity --principal=100000 --periods=3 --interest=12 --cents", "|     1 |   34003 |     1000 |     33003 |   66997 |", 3},
+		{"--type=diff --principal=1000 --periods=4 --interest=12", "|     1 |     260 |       10 |       250 |     750 |", 4},
+	} {
+		out, _, _ := runArgs(t, c.args+" --format=markdown")
+		lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+
+		if len(lines) != c.rows+2 || lines[0] != "| Month | Payment | Interest | Principal | Balance |" ||
+			lines[1] != "| ----: | ------: | -------: | --------: | ------: |" || lines[2] != c.first {
+			t.Errorf("%s:\n%s", c.args, out)
+		}
+
+		for _, line := range lines {
+			if len(line) != len(lines[0]) {
+				t.Errorf("%s: %q isn't aligned with the header", c.args, line)
+			}
+		}
+	}
+}
+
+// TestMultipleFormats prints the text summary and saves the JSON result of
+// the same run.
+func TestMultipleFormats(t *testing.T) {
+	const loan = "--type=annuity --principal=1000 --periods=12 --interest=12"
+	path := filepath.Join(t.TempDir(), "out.json")
+
+	out, _, _ := runArgs(t, loan+" --format=text,json:"+path)
+	if want, _, _ := runArgs(t, loan); out != want {
+		t.Errorf("stdout %q, want %q", out, want)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var r Result
+	if err := json.Unmarshal(data, &r); err != nil {
+		t.Fatalf("%v in %s", err, data)
+	}
+	if r.Payment.String() != "89" || r.Overpayment.String() != "68" || len(r.Schedule) != 12 {
+		t.Errorf("saved %+v", r)
+	}
+
+	// only one of the formats can go to stdout
+	if out, _, _ := runArgs(t, loan+" --format=text,json"); out != "Unknown format \"text,json\"\n" {
+		t.Errorf("two formats to stdout: %q", out)
+	}
+}
+
+// TestPretty checks that -pretty indents the JSON, schedule included, into
+// the same result as the compact line.
+func TestPretty(t *testing.T) {
+	for _, loan := range []string{
+		"--type=annuity --principal=1000 --periods=2 --interest=12 --format=json",
+		"--type=annuity --principal=1000 --periods=2 --interest=12 --format=json --schedule-only",
+		"--type=diff --principal=1000 --periods=2 --interest=12 --format=json",
+	} {
+		compact, _, _ := runArgs(t, loan)
+		pretty, _, _ := runArgs(t, loan+" --pretty")
+
+		if strings.Count(compact, "\n") != 1 || strings.Contains(compact, "  ") {
+			t.Errorf("%s: not compact:\n%s", loan, compact)
+		}
+		if !strings.HasPrefix(pretty, "{\n  \"schema_version\": 1,\n") || !strings.Contains(pretty, "\n  \"schedule\": [\n    {\n      \"month\": 1,\n") {
+			t.Errorf("%s: not indented:\n%s", loan, pretty)
+		}
+
+		var c, p bytes.Buffer
+		if err := json.Compact(&p, []byte(pretty)); err != nil {
+			t.Fatalf("%s: %v", loan, err)
+		}
+		json.Compact(&c, []byte(compact))
+		if c.String() != p.String() {
+			t.Errorf("%s: %s\nagainst %s", loan, p.String(), c.String())
+		}
+	}
+}
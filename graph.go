@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const graphRows = 20
+
+// displayGraph charts the balance owed at the start of each group of months.
+func displayGraph(rows []ScheduleRow) {
+	if len(rows) == 0 {
+		return
+	}
+
+	bucket := (len(rows) + graphRows - 1) / graphRows
+	top := moneyOf(principal)
+	label := len(strconv.Itoa(len(rows)))
+	width := terminalWidth() - label - len(top.String()) - 5
+
+	fmt.Fprintln(stdout)
+
+	for k := 0; k < len(rows); k += bucket {
+		balance := top
+		if k > 0 {
+			balance = rows[k-1].Balance
+		}
+
+		bar := 0
+		if top > 0 && width > 0 {
+			bar = int(float64(balance) / float64(top) * float64(width))
+		}
+
+		fmt.Fprintf(stdout, "%*d | %s %s\n", label, rows[k].Month, strings.Repeat("#", bar), balance.Ceil())
+	}
+}
+
+func terminalWidth() int {
+	if w, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && w > 0 {
+		return w
+	}
+
+	return 80
+}
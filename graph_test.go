@@ -0,0 +1,53 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// graphBars returns the bar lengths of the chart at the end of out.
+func graphBars(out string) []int {
+	chart := out[strings.LastIndex(out, "\n\n")+2:]
+
+	var bars []int
+	for _, line := range strings.Split(strings.TrimSuffix(chart, "\n"), "\n") {
+		_, bar, _ := strings.Cut(line, "| ")
+		bars = append(bars, strings.Count(bar, "#"))
+	}
+
+	return bars
+}
+
+func TestGraph(t *testing.T) {
+	t.Setenv("COLUMNS", "60")
+
+	for _, loan := range []string{
+		"--type=annuity --principal=1000 --periods=6 --interest=12",
+		"--type=annuity --principal=1000000 --periods=360 --interest=7",
+		"--type=diff --principal=1000000 --periods=360 --interest=7",
+		"--type=diff --principal=1000 --periods=6 --interest=12",
+	} {
+		out, _, _ := runArgs(t, loan+" --graph")
+
+		bars := graphBars(out)
+		if len(bars) < 6 || len(bars) > graphRows {
+			t.Fatalf("%s: %d bars:\n%s", loan, len(bars), out)
+		}
+
+		for k := 1; k < len(bars); k++ {
+			if bars[k] > bars[k-1] {
+				t.Errorf("%s: bar %d is longer than the one before:\n%s", loan, k+1, out)
+			}
+		}
+		if bars[0] <= bars[len(bars)-1] || bars[0] > 60 {
+			t.Errorf("%s: the chart doesn't fit or fall:\n%s", loan, out)
+		}
+	}
+
+	// an annuity repays slowly at first, so its chart bulges above the diff one
+	annuity, _, _ := runArgs(t, "--type=annuity --principal=1000000 --periods=360 --interest=7 --graph")
+	diff, _, _ := runArgs(t, "--type=diff --principal=1000000 --periods=360 --interest=7 --graph")
+	if a, d := graphBars(annuity), graphBars(diff); a[len(a)/2] <= d[len(d)/2] {
+		t.Errorf("halfway the annuity bar is %d, the diff one %d", a[len(a)/2], d[len(d)/2])
+	}
+}
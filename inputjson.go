@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// applyInputJSON sets the flags from the keys of a single JSON object, read
+// from the -input-json file or stdin for "-", as if given on the command line.
+func applyInputJSON(fs *flag.FlagSet) error {
+	var r io.Reader = os.Stdin
+	if inputJSON != "-" {
+		f, err := os.Open(inputJSON)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		r = f
+	}
+
+	var params map[string]any
+
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	if err := dec.Decode(&params); err != nil {
+		return fmt.Errorf(msg("bad-input-json"), err)
+	}
+
+	for name, v := range params {
+		if fs.Lookup(name) == nil || name == "input-json" || name == "run" {
+			return fmt.Errorf(msg("unknown-input-key"), name)
+		}
+
+		switch v.(type) {
+		case string, json.Number, bool:
+		default:
+			return fmt.Errorf(msg("bad-input-value"), name)
+		}
+
+		if err := fs.Set(name, fmt.Sprint(v)); err != nil {
+			return err
+		}
+	}
+
+	formatGiven := false
+	fs.Visit(func(f *flag.Flag) { formatGiven = formatGiven || f.Name == "format" })
+	if !formatGiven {
+		return fs.Set("format", "json")
+	}
+
+	return nil
+}
+
+// printError reports a failed calculation, as a JSON object under
+// -input-json or -format=json so that the output stays machine-readable.
+func printError(err error) {
+	if inputJSON == "" && stdoutFormat != "json" {
+		fmt.Fprintln(stdout, err)
+		return
+	}
+
+	type jsonError struct {
+		Code    string   `json:"code"`
+		Message string   `json:"message"`
+		Fields  []string `json:"fields,omitempty"`
+	}
+
+	e := jsonError{Code: "error", Message: err.Error()}
+
+	var pe paramError
+	if errors.As(err, &pe) {
+		e.Code, e.Fields = pe.code, pe.fields
+	}
+
+	out, _ := json.Marshal(struct {
+		SchemaVersion int       `json:"schema_version"`
+		Error         jsonError `json:"error"`
+	}{schemaVersion, e})
+	fmt.Fprintln(stdout, string(out))
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+// pipeStdin makes the content the standard input for the test.
+func pipeStdin(t *testing.T, content string) {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	saved := os.Stdin
+	os.Stdin = r
+	t.Cleanup(func() { os.Stdin, _ = saved, r.Close() })
+}
+
+func TestInputJSON(t *testing.T) {
+	for _, c := range []struct {
+		input   string
+		message string
+	}{
+		{`{"type":"annuity","principal":1000,"periods":12,"interest":5}`, ""},
+		{`{"type": "annuity", "principal": "1k", "periods": 12, "interest": 5, "exact": true}`, ""},
+		{`{"type":"annuity","principal":1000,`, "Malformed input JSON: unexpected EOF"},
+		{`[1000, 12, 5]`, "Malformed input JSON: json: cannot unmarshal array into Go value of type map[string]interface {}"},
+		{`{"type":"annuity","bogus":1}`, `Unknown input key "bogus"`},
+		{`{"type":"annuity","run":"--principal=1"}`, `Unknown input key "run"`},
+		{`{"type":"annuity","principal":[1000]}`, `Input key "principal" must be a string, number or boolean`},
+		{`{"type":"annuity","principal":1000}`, "Incorrect parameters"},
+	} {
+		pipeStdin(t, c.input)
+		out, _, _ := runArgs(t, "--input-json=-")
+
+		var r struct {
+			Result
+			Error *struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal([]byte(out), &r); err != nil {
+			t.Errorf("%s: not JSON: %v in %q", c.input, err, out)
+			continue
+		}
+
+		if c.message == "" && (r.Error != nil || r.Payment != 8600 || r.Principal != 100000) {
+			t.Errorf("%s: %s", c.input, out)
+		}
+		if c.message != "" && (r.Error == nil || r.Error.Message != c.message) {
+			t.Errorf("%s: %s, want %q", c.input, out, c.message)
+		}
+	}
+
+	path := writeFile(t, "loan.json", `{"type":"diff","principal":1000,"periods":2,"interest":5}`)
+	if out, _, _ := runArgs(t, "--input-json="+path+" --format=text"); out != "Month 1: payment is 505\nMonth 2: payment is 503\n\nOverpayment = 8\n" {
+		t.Errorf("from a file as text: %q", out)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// displayInterestCap checks the overpayment against -interest-cap-percent
+// of the principal, and if it's over, finds the longest term or, when
+// solving for the period, the smallest payment that keeps within it.
+func displayInterestCap(action CalcType, overpayment Money) {
+	limit := moneyOf(principal * interestCap / 100)
+	if overpayment <= limit {
+		fmt.Fprintf(stdout, msg("cap-within"), interestCap, limit)
+		return
+	}
+
+	savedPayment, savedPeriods := payment, periods
+	defer func() { payment, periods = savedPayment, savedPeriods }()
+
+	if action == CalcPeriod {
+		lo, hi := payment, math.Ceil(principal*(1+getInterestRate()))
+
+		for hi-lo > 1 {
+			payment = math.Floor((lo + hi) / 2)
+			periods = calculatePeriod()
+
+			if calculateOverpayment() <= limit {
+				hi = payment
+			} else {
+				lo = payment
+			}
+		}
+
+		payment = hi
+		periods = calculatePeriod()
+		fmt.Fprintf(stdout, msg("cap-payment"), interestCap, limit, moneyOf(payment), formatPeriods(periods))
+
+		return
+	}
+
+	for periods = savedPeriods - 1; periods > 0; periods-- {
+		payment = getAmortizer().annuityPayment()
+
+		if calculateOverpayment() <= limit {
+			fmt.Fprintf(stdout, msg("cap-term"), interestCap, limit, formatPeriods(periods), moneyOf(payment))
+			return
+		}
+	}
+
+	fmt.Fprintf(stdout, msg("cap-none"), interestCap, limit)
+}
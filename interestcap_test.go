@@ -0,0 +1,34 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInterestCap(t *testing.T) {
+	for _, c := range []struct{ args, want string }{
+		{"--principal=100000 --periods=120 --interest=6", "Overpayment is within the 50% cap of 50000\n"},
+		{"--principal=100000 --periods=360 --interest=6",
+			"Overpayment exceeds the 50% cap of 50000, the longest term within it is 14 years and 6 months with a payment of 862\n"},
+		{"--principal=100000 --payment=700 --interest=6",
+			"Overpayment exceeds the 50% cap of 50000, the smallest payment within it is 865 over 14 years and 5 months\n"},
+	} {
+		out, _, _ := runArgs(t, "--type=annuity --interest-cap-percent=50 "+c.args)
+		if !strings.HasSuffix(out, c.want) {
+			t.Errorf("%s:\n%s", c.args, out)
+		}
+	}
+
+	// the term and the payment found are the longest and smallest within
+	// the cap, a month more or a unit less exceeding it
+	for _, c := range []struct{ args, want string }{
+		{"--principal=100000 --periods=174 --interest=6", "Your annuity payment = 862!\nOverpayment = 49988\n"},
+		{"--principal=100000 --periods=175 --interest=6", "Your annuity payment = 859!\nOverpayment = 50325\n"},
+		{"--principal=100000 --payment=865 --interest=6", "It will take 14 years and 5 months to repay this loan!\nFinal payment will be 863 instead of 865\nOverpayment = 49645\n"},
+		{"--principal=100000 --payment=864 --interest=6", "It will take 14 years and 6 months to repay this loan!\nFinal payment will be 274 instead of 864\nOverpayment = 50336\n"},
+	} {
+		if out, _, _ := runArgs(t, "--type=annuity "+c.args); out != c.want {
+			t.Errorf("%s:\n%s", c.args, out)
+		}
+	}
+}
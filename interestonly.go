@@ -0,0 +1,45 @@
+package main
+
+import "fmt"
+
+// doInterestOnlyPhase pays only the interest for -interest-only-months,
+// after which the unchanged principal amortizes over the rest of the term.
+func doInterestOnlyPhase() error {
+	if interestOnlyMonths >= periods {
+		return outOfRange("interest-only-months")
+	}
+
+	interestPaid := moneyOf(roundUp(principal * getInterestRate()))
+
+	savedPeriods := periods
+	periods -= interestOnlyMonths
+	payment = getAmortizer().annuityPayment()
+	overpayment := calculateOverpayment().Add(interestPaid.Mul(float64(interestOnlyMonths)))
+	amortizing := annuitySchedule()
+	periods = savedPeriods
+
+	var schedule []ScheduleRow
+
+	balance := moneyOf(principal)
+	for m := 1; m <= interestOnlyMonths; m++ {
+		schedule = append(schedule, newScheduleRow(schedule, m, interestPaid, interestPaid, balance))
+	}
+
+	for _, r := range amortizing {
+		schedule = append(schedule, newScheduleRow(schedule, r.Month+interestOnlyMonths, r.Payment, r.InterestPortion, r.Balance))
+	}
+
+	if done, err := emitResult(newResult(overpayment, schedule)); done || err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, msg("interest-only-phase"), interestOnlyMonths, interestPaid)
+	fmt.Fprintf(stdout, msg("amortizing-phase"), periods-interestOnlyMonths, moneyOf(payment))
+	fmt.Fprintf(stdout, msg("overpayment"), overpayment)
+
+	if showSchedule {
+		displaySchedule(schedule)
+	}
+
+	return nil
+}
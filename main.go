@@ -4,8 +4,10 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"math"
 	"os"
+	"sort"
 	"strings"
 )
 
@@ -18,28 +20,306 @@ const (
 	CalcPrincipal
 	CalcPeriod
 	CalcPayment
+	CalcInterest
+	CalcMaxPrincipal
+	CalcMaxPeriod
+	CalcOffers
+	CalcInterestOnly
+	CalcRefinance
+	CalcDownPayment
+	CalcRateSweep
+	CalcFrequencyComparison
+	CalcStressTest
+	CalcConsolidation
+	CalcRoundingComparison
+	CalcAffordability
 )
 
 var (
-	payment, principal, interest float64
-	periods                      int
-	method                       string
+	payment, principal, interest   float64
+	maxOverpayment, extraMonthly   float64
+	monthlyTax, monthlyInsurance   float64
+	solverTolerance                float64
+	newInterest, closingCosts      float64
+	price, targetPayment, fee      float64
+	residual, warnRatio            float64
+	budget, downPayment            float64
+	interestSubsidy, interestCap   float64
+	roundPaymentUpTo               float64
+	stressRate, maxPayment         float64
+	stubInterest                   float64
+	solverMaxIter, warnTerm        int
+	payoffAt, originalPeriods      int
+	interestOnlyMonths, shortenBy  int
+	periods, years                 int
+	ratePrecision                  int
+	method, offersFile, lang       string
+	inputJSON, auditLog, batchFile string
+	compareTo, consolidateFile     string
+	ratesFile, product             string
+	outputFormat, compounding      string
+	paymentFrequency               string
+	solve, stubMode, query         string
+	favor, roundPolicy             string
+	startDate, firstPaymentDate    dateValue
+	exact, interestOnly, explain   bool
+	interestByYear, scheduleOnly   bool
+	scheduleByYear                 bool
+	capitalizeFees, compareFreq    bool
+	strict, showExact              bool
+	explainParse, listModes        bool
+	fractionalPeriod, helpExamples bool
+	firstInterest                  bool
+	validateSum, displayRounding   bool
+	graph, verbose, showSchedule   bool
+	centsMode, reproduce           bool
+	quietErrors, interestIsEAR     bool
+	diffFixedInterest              bool
+	compareRounding, crossover     bool
+	selfCheck, pretty, seedCorpus  bool
+	reduceFinal, compactSchedule   bool
+	scheduleReverse                bool
+	disbursements, recasts         monthValues
+	stepUps                        monthValues
+	skipMonths                     monthSet
+	runs                           stringList
+	outputTemplate                 templateValue
+	rateSweep                      floatList
+	sweepTerms                     intList
+
+	// provided holds the names of the flags given on the command line
+	provided map[string]bool
 )
 
-func init() {
-	flag.Float64Var(&payment, "payment", -1, "The payment amount")
-	flag.Float64Var(&principal, "principal", -1, "The loan principal")
-	flag.IntVar(&periods, "periods", -1, "The number of months needed to repay the loan")
-	flag.Float64Var(&interest, "interest", -1, "The annual interest rate")
-	flag.StringVar(&method, "type", "", `The type of payment: "annuity" or "diff"`)
-	flag.Parse()
+// stdout and stderr are where run writes the output and the warnings.
+var stdout, stderr io.Writer = os.Stdout, os.Stderr
+
+// newFlagSet binds the command line flags to their variables, resetting
+// each of them to its default.
+func newFlagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	disbursements, skipMonths, runs, outputTemplate = nil, nil, nil, templateValue{}
+	rateSweep, sweepTerms, recasts, stepUps = nil, nil, nil, nil
+	startDate, firstPaymentDate = dateValue{}, dateValue{}
+	resolvedFlags = make(map[string]string)
+
+	fs.Var(newAmountValue(-1, &payment), "payment", "The payment amount")
+	fs.Var(newAmountValue(-1, &principal), "principal", "The loan principal")
+	fs.IntVar(&periods, "periods", -1, "The number of months needed to repay the loan")
+	fs.IntVar(&years, "years", -1, "The number of years needed to repay the loan, instead of -periods")
+	fs.Var(newFloatValue(-1, &interest), "interest", "The annual interest rate")
+	fs.Var(newAmountValue(-1, &maxOverpayment), "max-overpayment", "The largest acceptable overpayment, to solve for the principal or, given the principal, the longest term")
+	fs.Var(newAmountValue(0, &roundPaymentUpTo), "round-payment-up-to", "Show the savings of paying the payment rounded up to a multiple of this")
+	fs.Var(newAmountValue(0, &extraMonthly), "extra-monthly", "An extra amount paid with every annuity payment")
+	fs.Var(newAmountValue(0, &monthlyTax), "monthly-tax", "The property tax added to each monthly outlay")
+	fs.Var(newAmountValue(0, &monthlyInsurance), "monthly-insurance", "The insurance added to each monthly outlay")
+	fs.Var(newFloatValue(1e-6, &solverTolerance), "solver-tolerance", "The largest residual the numeric solvers accept")
+	fs.IntVar(&solverMaxIter, "solver-max-iter", 200, "The number of iterations after which the numeric solvers give up")
+	fs.BoolVar(&strict, "strict", false, "Require -solve for annuities and reject values the calculation doesn't use")
+	fs.StringVar(&solve, "solve", "", `What to solve for, instead of the omitted value: "payment", "principal", "period" or "interest"`)
+	fs.Var(newFloatValue(-1, &newInterest), "new-interest", "The annual interest rate of a refinanced loan, to find the break-even month")
+	fs.Var(newAmountValue(0, &closingCosts), "closing-costs", "The closing costs of the refinanced loan")
+	fs.Var(&rateSweep, "rate-sweep", `Annual interest rates as "rate,..." to tabulate the payments of the principal for, by -terms`)
+	fs.Var(&sweepTerms, "terms", `The terms in months as "months,..." for -rate-sweep`)
+	fs.Var(newAmountValue(0, &fee), "fee", "The fees of the loan, paid upfront unless capitalized")
+	fs.Var(newAmountValue(0, &residual), "residual", "The balance an annuity is meant to leave at the end of the term, lowering the payment")
+	fs.BoolVar(&capitalizeFees, "capitalize-fees", false, "Add -fee, or the fees of -offers, to the principal instead of paying them upfront")
+	fs.StringVar(&roundPolicy, "round", "", `How computed payments are rounded: "ceil", "floor", "nearest" (halves up), "half-even" (halves to the even neighbor), or "none" leaving every figure unrounded; by -favor if omitted`)
+	fs.BoolVar(&compareRounding, "compare-rounding", false, "Compare the payment and overpayment under every -round policy")
+	fs.BoolVar(&compareFreq, "compare-frequency", false, "Compare paying monthly with paying half as much biweekly")
+	fs.Var(newFloatValue(-1, &stressRate), "stress-rate", "The annual interest rate the borrower qualifies at, to find the principal within -max-payment")
+	fs.Var(newAmountValue(-1, &maxPayment), "max-payment", "The largest payment the borrower qualifies for at -stress-rate")
+	fs.Var(newAmountValue(-1, &budget), "budget", "The monthly budget, -monthly-tax and -monthly-insurance included, to find the affordable price for")
+	fs.Var(newAmountValue(0, &downPayment), "down-payment", "The down payment added to the principal the -budget affords")
+	fs.Var(newAmountValue(-1, &price), "price", "The purchase price, to find the down payment for -target-payment")
+	fs.Var(newAmountValue(-1, &targetPayment), "target-payment", "The payment to reach by a down payment on -price")
+	fs.IntVar(&warnTerm, "warn-term", 480, "Warn when the computed number of months exceeds this")
+	fs.Var(newFloatValue(0, &warnRatio), "warn-overpayment-ratio", "Warn when the overpayment exceeds this multiple of the principal, e.g. 1 for more interest than principal")
+	fs.Var(newFloatValue(0, &interestSubsidy), "interest-subsidy", "The percentage points of the annual rate paid by a subsidy")
+	fs.Var(newFloatValue(-1, &interestCap), "interest-cap-percent", "The largest overpayment allowed, as a percentage of the principal")
+	fs.StringVar(&method, "type", "", `The type of payment: "annuity" or "diff"`)
+	fs.StringVar(&consolidateFile, "consolidate", "", "A CSV file of loans (name, principal, rate, term) to find the weighted rate and blended payment of")
+	fs.StringVar(&ratesFile, "rates-file", "", "A JSON file of annual interest rates by product, for -product")
+	fs.StringVar(&product, "product", "", "The product in -rates-file whose rate is the -interest, unless that's given")
+	fs.StringVar(&offersFile, "offers", "", "A CSV file of lender offers (name, rate, term, fees) to compare for the principal")
+	fs.BoolVar(&interestOnly, "interest-only", false, "Pay only the interest each month and the whole principal at the end")
+	fs.IntVar(&interestOnlyMonths, "interest-only-months", 0, "Pay only the interest for this many months, then amortize the principal over the rest of -periods")
+	fs.BoolVar(&explain, "explain", false, "Break the overpayment down into the interest paid each month")
+	fs.BoolVar(&selfCheck, "self-check", false, "Check that the annuity schedule pays the payment to the last month and sums to the total cost")
+	fs.BoolVar(&validateSum, "validate-sum", false, "Check that the printed diff payments add up to the principal plus overpayment")
+	fs.BoolVar(&displayRounding, "round-display-only", false, "Keep payments, principals and overpayments unrounded until they are printed")
+	fs.BoolVar(&fractionalPeriod, "fractional-period", false, "Show the exact number of months when solving for the period")
+	fs.BoolVar(&firstInterest, "first-interest", false, "Show the interest and principal parts of the first payment")
+	fs.BoolVar(&helpExamples, "help-examples", false, "Print example commands and what each calculates")
+	fs.BoolVar(&listModes, "list-modes", false, "List the calculations and the values each needs")
+	fs.BoolVar(&explainParse, "explain-parse", false, "Report the flags given, the checks made on them and the calculation inferred, before calculating")
+	fs.BoolVar(&showExact, "show-exact", false, "Print the unrounded payment, principal and overpayment next to the rounded ones")
+	fs.BoolVar(&reduceFinal, "reduce-final-payment", false, "Count the reduced final payment in the overpayment instead of a full one")
+	fs.BoolVar(&verbose, "verbose", false, "Show additional figures derived from the result")
+	fs.BoolVar(&showSchedule, "schedule", false, "Print the monthly schedule split into interest and principal")
+	fs.BoolVar(&scheduleReverse, "schedule-reverse", false, "Print the schedule from the last month to the first, counting down what's left to pay")
+	fs.BoolVar(&compactSchedule, "compact-schedule", false, "Print the payments of the schedule with runs of equal ones on a line")
+	fs.BoolVar(&scheduleByYear, "schedule-by-year", false, "Print the payments, interest, principal and year-end balance of each calendar year")
+	fs.BoolVar(&crossover, "crossover", false, "Show the first month whose principal part exceeds its interest part")
+	fs.BoolVar(&interestByYear, "total-interest-breakdown-by-year", false, "Sum the interest of the schedule by calendar year, counting from -start-date")
+	fs.BoolVar(&scheduleOnly, "schedule-only", false, "Print only the schedule, in the -format given, without the summary")
+	fs.BoolVar(&graph, "graph", false, "Chart the remaining balance over time")
+	fs.BoolVar(&centsMode, "cents", false, "Take and print all amounts as whole cents")
+	fs.BoolVar(&reproduce, "reproduce", false, "Print the command line reproducing the result")
+	fs.BoolVar(&exact, "exact", false, "Use exact rational arithmetic for money calculations")
+	fs.Var(&recasts, "recast", `Prepayments after which the balance is re-amortized over the rest of the term, as "month:amount,..."`)
+	fs.IntVar(&originalPeriods, "original-periods", -1, "The original number of months of a loan of which -periods are left, to show how far into it you are")
+	fs.IntVar(&shortenBy, "shorten-by-years", 0, "Show the payment that repays the loan this many years sooner, and the interest it saves")
+	fs.IntVar(&payoffAt, "payoff-at", -1, "The number of payments after which to quote the payoff amount")
+	fs.Var(&stepUps, "step-up", `Multipliers of the payment from the given months on, as "month:factor,..."; the base payment is solved for`)
+	fs.Var(&disbursements, "disbursements", `The principal tranches of a construction loan as "month:amount,..."`)
+	fs.BoolVar(&diffFixedInterest, "diff-fixed-interest", false, "Charge the diff interest on the original principal every month, not on the balance")
+	fs.BoolVar(&interestIsEAR, "interest-is-ear", false, "Take -interest as the effective annual rate, compounded once a year")
+	fs.StringVar(&compounding, "compounding", "monthly", `How often a year the interest compounds: a count or e.g. "monthly", "semiannual", "weekly"`)
+	fs.StringVar(&paymentFrequency, "payment-frequency", "monthly", "How often a year the payments are made, like -compounding; -periods counts payments")
+	fs.BoolVar(&pretty, "pretty", false, "Indent the JSON output")
+	fs.StringVar(&outputFormat, "format", "text", `The output format: "text", "env", "json", "csv" or "markdown"; several as e.g. "text,json:out.json" write those with a path to files`)
+	fs.StringVar(&query, "query", "", `Print only the value at a path such as "overpayment" or "month[59].balance"`)
+	fs.StringVar(&lang, "lang", "en", `The language of the output: "en" or "de"`)
+	fs.Var(&skipMonths, "skip-months", `The months of an annuity without a payment, as "month,..."`)
+	fs.Var(&startDate, "start-date", "The date the loan starts, as YYYY-MM-DD; defaults to today")
+	fs.Var(&firstPaymentDate, "first-payment-date", "The date of the first payment when it's later than a month after -start-date")
+	fs.StringVar(&stubMode, "stub-interest", "separate", `How to pay the interest of a longer first period: "separate" or "capitalize"`)
+	fs.Var(&runs, "run", "The flags of one of several calculations to run in turn; may be repeated")
+	fs.StringVar(&batchFile, "batch", "", "A CSV file of calculations to run in turn, a row each, with the flag names as the header")
+	fs.BoolVar(&seedCorpus, "seed-corpus", false, "Print a CSV of edge-case loans for -batch")
+	fs.BoolVar(&quietErrors, "quiet-errors", false, "Count the failed -run calculations instead of printing their errors, exiting with 1 if any failed")
+	fs.Var(&outputTemplate, "template", "A text/template used to render the result instead of the built-in output")
+	fs.StringVar(&compareTo, "compare-to", "", "A JSON result saved with -format=json to show the changes against")
+	fs.StringVar(&auditLog, "audit-log", "", "A file to append a JSON record of the inputs and output of the calculation to")
+	fs.StringVar(&inputJSON, "input-json", "", `A JSON object of flag values to read, "-" for stdin; the result is JSON too`)
+	fs.StringVar(&favor, "favor", "lender", `Who the rounding of the annuity payment favors: "lender" rounds up, "borrower" down with the final payment clearing the balance`)
+	fs.IntVar(&ratePrecision, "rate-precision", 2, "The number of decimals shown for the interest rate")
+
+	return fs
 }
 
 func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run performs a single invocation with the given arguments, writing to
+// the given output and error streams, and returns the process exit code.
+func run(args []string, out, errOut io.Writer) int {
+	stdout, stderr = out, errOut
+
+	code, err := execute(args, runSettings{})
+	if err != nil {
+		printError(err)
+	}
+
+	return code
+}
+
+// runSettings are the flags of the invocation a -run inherits, since each
+// run resets the flags.
+type runSettings struct {
+	quiet    bool
+	auditLog string
+}
+
+// execute is run without printing the error the calculation failed with.
+func execute(args []string, outer runSettings) (int, error) {
+	fs := newFlagSet()
+	fs.SetOutput(stderr)
+	if outer.quiet {
+		fs.SetOutput(io.Discard)
+	}
+
+	if err := fs.Parse(args); err == flag.ErrHelp {
+		return 0, nil
+	} else if err != nil {
+		auditParseError(outer.auditLog, args, err)
+		return 2, nil
+	}
+
+	if auditLog == "" {
+		auditLog = outer.auditLog
+	}
+
+	if batchFile != "" {
+		batch, err := readBatch(batchFile)
+		if err != nil {
+			return 0, err
+		}
+		runs = append(runs, batch...)
+	}
+
+	if len(runs) > 0 {
+		return runAll(runs, runSettings{quietErrors, auditLog}), nil
+	}
+
+	if seedCorpus {
+		return 0, displaySeedCorpus()
+	}
+
+	if listModes {
+		displayModes()
+		return 0, nil
+	}
+
+	if helpExamples {
+		displayExamples()
+		return 0, nil
+	}
+
+	if inputJSON != "" {
+		if err := applyInputJSON(fs); err != nil {
+			return 0, err
+		}
+	}
+
+	provided = make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { provided[f.Name] = true })
+
+	var ok bool
+	if stdoutFormat, fileOutputs, ok = parseFormats(outputFormat); !ok {
+		if !outer.quiet {
+			fmt.Fprintf(stdout, msg("unknown-format"), outputFormat)
+		}
+		auditParseError(auditLog, args, fmt.Errorf(strings.TrimSuffix(msg("unknown-format"), "\n"), outputFormat))
+		return 2, nil
+	}
+
+	given := givenFlags(fs)
+
+	startParseReport(fs)
+
+	audit := startAudit(fs)
+	err := calculate()
+	audit.finish(err)
+
+	if err != nil {
+		return 0, err
+	}
+
+	// the command goes to stderr when stdout is for a program to read
+	if reproduce && proseOutput() {
+		fmt.Fprintln(stdout, canonicalCommand(given))
+	} else if reproduce {
+		fmt.Fprintln(stderr, canonicalCommand(given))
+	}
+
+	return 0, nil
+}
+
+// calculate performs the action the flags ask for.
+func calculate() error {
 	action, err := getAction()
+	if err == nil && !checked("-format, -query and -template only for a loan", resultModes[action] || proseOutput()) {
+		action, err = CalcInvalid, paramError{"text-only", []string{"format", "query", "template"}}
+	}
+
+	if explainParse {
+		displayParseReport(action, err)
+	}
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(0)
+		return err
 	}
 
 	switch action {
@@ -47,26 +327,384 @@ func main() {
 		err = doAnnualCalculations()
 	case CalcDiff:
 		err = doDiffCalculations()
+	case CalcOffers:
+		err = doOffersComparison()
+	case CalcInterestOnly:
+		err = doInterestOnlyCalculations()
+	case CalcRefinance:
+		err = doRefinanceCalculations()
+	case CalcDownPayment:
+		err = doDownPaymentCalculations()
+	case CalcRateSweep:
+		err = doRateSweep()
+	case CalcFrequencyComparison:
+		err = doFrequencyComparison()
+	case CalcStressTest:
+		err = doStressTest()
+	case CalcConsolidation:
+		err = doConsolidation()
+	case CalcRoundingComparison:
+		err = doRoundingComparison()
+	case CalcAffordability:
+		err = doAffordability()
 	}
 
-	if err != nil {
-		fmt.Println(err)
+	return err
+}
+
+// resultModes are the calculations with a Result for -format, -query and
+// -template; the others only print their tables.
+var resultModes = map[CalcType]bool{CalcAnnual: true, CalcDiff: true, CalcInterestOnly: true}
+
+// proseOutput reports whether only the built-in text output is asked for.
+func proseOutput() bool {
+	return stdoutFormat == "text" && len(fileOutputs) == 0 && query == "" && outputTemplate.tmpl == nil
+}
+
+// runAll performs each -run in a labeled block of its own, carrying on
+// past failed ones.
+func runAll(runs []string, outer runSettings) int {
+	failed := 0
+
+	for k, r := range runs {
+		if k > 0 {
+			fmt.Fprintln(stdout)
+		}
+
+		fmt.Fprintf(stdout, msg("run-header"), k+1, r)
+
+		code, err := execute(strings.Fields(r), outer)
+		if err != nil || code != 0 {
+			failed++
+		}
+		if err != nil && !outer.quiet {
+			printError(err)
+		}
+	}
+
+	if !outer.quiet {
+		return 0
 	}
+
+	fmt.Fprintln(stdout)
+	fmt.Fprintf(stdout, msg("runs-failed"), failed, len(runs))
+	if failed > 0 {
+		return 1
+	}
+
+	return 0
+}
+
+// isProvided reports whether all the named flags were given, counting
+// -years as -periods.
+func isProvided(names ...string) bool {
+	for _, name := range names {
+		if !provided[name] && !(name == "periods" && provided["years"]) {
+			return false
+		}
+	}
+
+	return true
 }
 
 func incorrectParameters() error {
-	return errors.New("Incorrect parameters")
+	return errors.New(msg("incorrect-parameters"))
+}
+
+// paramError is an incorrect-parameters error attributed to the flags at
+// fault, for the JSON error output.
+type paramError struct {
+	code   string
+	fields []string
+}
+
+// Error explains the flags -strict found unused and an output the
+// calculation can't give, leaving the plain message every other incorrect
+// input has always had.
+func (e paramError) Error() string {
+	switch e.code {
+	case "unused":
+		return fmt.Sprintf(msg("unused-flags"), "-"+strings.Join(e.fields, ", -"))
+	case "text-only":
+		return msg("text-only")
+	}
+
+	return msg("incorrect-parameters")
+}
+
+func outOfRange(fields ...string) error {
+	return paramError{"out-of-range", fields}
+}
+
+// underSpecified names those of the given flags that weren't provided.
+func underSpecified(names ...string) error {
+	var fields []string
+
+	for _, name := range names {
+		if !isProvided(name) {
+			fields = append(fields, name)
+		}
+	}
+
+	return paramError{"under-specified", fields}
+}
+
+// amortizer computes the money figures of a loan, so that the exact mode
+// can swap the float64 arithmetic for a rational one.
+type amortizer interface {
+	annuityPrincipal() float64
+	annuityPayment() float64
+	diffPayments() []float64
+	annuitySchedule(amount Money) []ScheduleRow
+}
+
+type floatAmortizer struct{}
+
+func (floatAmortizer) annuityPrincipal() float64 { return calculatePrincipal() }
+func (floatAmortizer) annuityPayment() float64   { return calculatePayment() }
+func (floatAmortizer) diffPayments() []float64   { return calculateDiffPayments() }
+
+func (floatAmortizer) annuitySchedule(amount Money) []ScheduleRow {
+	i := getInterestRate()
+	return buildAnnuitySchedule(amount, func(balance Money) Money { return balance.Mul(i) })
+}
+
+// floatExactLimit is the amount from which float64 payments may drift from
+// the rational ones by a cent or more; below 1e12 they are within a cent of
+// a 256-bit reference for terms of 1 to 1200 months at rates of 0.5% to 35%,
+// as TestLargePrincipalStability checks. Larger loans switch to the exact
+// arithmetic.
+const floatExactLimit = 1e12
+
+func getAmortizer() amortizer {
+	if exact || principal >= floatExactLimit || payment >= floatExactLimit {
+		return exactAmortizer{}
+	}
+
+	return floatAmortizer{}
 }
 
+// amountFlags are the flags of getAction's values that are amounts of
+// money, which must fit a Money.
+var amountFlags = []string{"payment", "principal", "max-overpayment", "price", "target-payment", "fee",
+	"residual", "budget", "down-payment", "max-payment", "round-payment-up-to"}
+
 func getAction() (CalcType, error) {
+	if err := applyRatesFile(); err != nil {
+		return CalcInvalid, err
+	}
+
+	// -1 stands for an omitted value, so a negative one must not be given
+	values := map[string]float64{
+		"payment":   payment,
+		"principal": principal,
+		"periods":   float64(periods),
+		"years":     float64(years),
+		"payoff-at": float64(payoffAt),
+
+		"interest-only-months": float64(interestOnlyMonths),
+		"shorten-by-years":     float64(shortenBy),
+
+		"original-periods": float64(originalPeriods),
+		"interest":         interest,
+
+		"max-overpayment": maxOverpayment,
+		"new-interest":    newInterest,
+		"price":           price,
+		"target-payment":  targetPayment,
+		"fee":             fee,
+		"residual":        residual,
+		"budget":          budget,
+		"down-payment":    downPayment,
+
+		"warn-overpayment-ratio": warnRatio,
+		"stress-rate":            stressRate,
+		"max-payment":            maxPayment,
+
+		"round-payment-up-to": roundPaymentUpTo,
+
+		"interest-cap-percent": interestCap,
+	}
+
+	var names = make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if provided[name] && !checked("-"+name+" >= 0", values[name] >= 0) {
+			return CalcInvalid, outOfRange(name)
+		}
+	}
+
+	for _, name := range amountFlags {
+		if provided[name] && !checked("-"+name+" to the cent", fitsMoney(values[name])) {
+			return CalcInvalid, outOfRange(name)
+		}
+	}
+
+	var okCompounding, okPayments bool
+	compoundingPerYear, okCompounding = frequencyPerYear(compounding)
+	paymentsPerYear, okPayments = frequencyPerYear(paymentFrequency)
+	if !checked("-compounding is a frequency", okCompounding) {
+		return CalcInvalid, outOfRange("compounding")
+	}
+
+	if !checked("-payment-frequency is a frequency", okPayments) {
+		return CalcInvalid, outOfRange("payment-frequency")
+	}
+
+	// an effective annual rate is a nominal one compounded annually
+	if interestIsEAR {
+		if !checked("not both -interest-is-ear and -compounding", !isProvided("compounding")) {
+			return CalcInvalid, paramError{"conflicting", []string{"interest-is-ear", "compounding"}}
+		}
+		compoundingPerYear = 1
+	}
+
+	if !checked("-favor is lender or borrower", favor == "lender" || favor == "borrower") {
+		return CalcInvalid, outOfRange("favor")
+	}
+
+	if !checked("-round is a rounding policy", validRounding(roundPolicy)) {
+		return CalcInvalid, outOfRange("round")
+	}
+
+	if !checked("0 <= -interest-subsidy <= -interest", interestSubsidy >= 0 && (!isProvided("interest") || interestSubsidy <= interest)) {
+		return CalcInvalid, outOfRange("interest-subsidy")
+	}
+
+	if !checked("-solver-tolerance > 0", solverTolerance > 0) {
+		return CalcInvalid, outOfRange("solver-tolerance")
+	}
+
+	if !checked("-solver-max-iter >= 1", solverMaxIter >= 1) {
+		return CalcInvalid, outOfRange("solver-max-iter")
+	}
+
+	if centsMode {
+		if err := fromCents(); err != nil {
+			return CalcInvalid, err
+		}
+	}
+
+	if !checked("-round-payment-up-to of at least the money unit", roundPaymentUpTo == 0 || moneyOf(roundPaymentUpTo) >= moneyUnit()) {
+		return CalcInvalid, outOfRange("round-payment-up-to")
+	}
+
+	if !checked("-schedule-only in a schedule -format", !scheduleOnly || scheduleFormats()) {
+		return CalcInvalid, outOfRange("format")
+	}
+
+	if startDate.IsZero() {
+		startDate.Time = today()
+		resolvedFlags["start-date"] = startDate.String()
+	}
+
+	if capitalizeFees && fee > 0 {
+		if !isProvided("principal") {
+			return CalcInvalid, underSpecified("principal")
+		}
+		principal += fee
+	}
+
+	if err := applyStubPeriod(); err != nil {
+		return CalcInvalid, err
+	}
+
+	if years >= 0 {
+		if !checked("not both -years and -periods", periods < 0) {
+			return CalcInvalid, paramError{"conflicting", []string{"periods", "years"}}
+		}
+		periods = years * paymentsPerYear
+	}
+
+	if originalPeriods >= 0 {
+		if !checked("-periods with -original-periods", periods >= 0) {
+			return CalcInvalid, underSpecified("periods")
+		}
+		if !checked("0 < -periods <= -original-periods", periods > 0 && periods <= originalPeriods) {
+			return CalcInvalid, outOfRange("original-periods")
+		}
+	}
+
+	action, err := selectAction()
+	if err != nil {
+		return CalcInvalid, err
+	}
+
+	if strict {
+		if err := checkStrict(action); err != nil {
+			return CalcInvalid, err
+		}
+	}
+
+	return action, nil
+}
+
+// selectAction picks the calculation from the flags given.
+func selectAction() (CalcType, error) {
+	if offersFile != "" {
+		return CalcOffers, nil
+	}
+
+	if consolidateFile != "" {
+		return CalcConsolidation, nil
+	}
+
+	if interestOnly || interestOnlyMonths > 0 {
+		return CalcInterestOnly, nil
+	}
+
+	if isProvided("new-interest") {
+		return CalcRefinance, nil
+	}
+
+	if isProvided("target-payment") {
+		return CalcDownPayment, nil
+	}
+
+	if isProvided("rate-sweep") {
+		return CalcRateSweep, nil
+	}
+
+	if compareFreq {
+		return CalcFrequencyComparison, nil
+	}
+
+	if isProvided("stress-rate") {
+		return CalcStressTest, nil
+	}
+
+	if isProvided("budget") {
+		return CalcAffordability, nil
+	}
+
+	var action CalcType
+
 	switch method {
 	case "annuity":
-		return CalcAnnual, nil
+		action = CalcAnnual
 	case "diff":
-		return CalcDiff, nil
+		action = CalcDiff
 	default:
-		return CalcInvalid, incorrectParameters()
+		return CalcInvalid, outOfRange("type")
+	}
+
+	if !checked("-diff-fixed-interest only with -type=diff", !diffFixedInterest || action == CalcDiff) {
+		return CalcInvalid, paramError{"conflicting", []string{"diff-fixed-interest", "type"}}
+	}
+
+	if !checked("-skip-months only with -type=annuity", len(skipMonths) == 0 || action != CalcDiff) {
+		return CalcInvalid, paramError{"conflicting", []string{"skip-months", "type"}}
+	}
+
+	if compareRounding {
+		return CalcRoundingComparison, nil
 	}
+
+	return action, nil
 }
 
 func doAnnualCalculations() error {
@@ -75,120 +713,962 @@ func doAnnualCalculations() error {
 		return err
 	}
 
+	if len(disbursements) > 0 && (action != CalcPayment || !validDisbursements()) {
+		return outOfRange("disbursements")
+	}
+
+	if len(stepUps) > 0 && (action != CalcPayment || len(disbursements) > 0 || !validStepUps()) {
+		return outOfRange("step-up")
+	}
+
+	if len(recasts) > 0 && (len(skipMonths) > 0 || len(disbursements) > 0) {
+		return paramError{"conflicting", []string{"recast", "skip-months", "disbursements"}}
+	}
+
+	if shortenBy > 0 {
+		if action != CalcPayment || len(skipMonths) > 0 || len(stepUps) > 0 || len(disbursements) > 0 {
+			return paramError{"conflicting", []string{"shorten-by-years"}}
+		}
+		if periods-shortenBy*paymentsPerYear <= 0 {
+			return outOfRange("shorten-by-years")
+		}
+	}
+
+	if selfCheck && (len(skipMonths) > 0 || len(stepUps) > 0) {
+		return paramError{"conflicting", []string{"self-check", "skip-months", "step-up"}}
+	}
+
+	if residual > 0 {
+		if action != CalcPayment || len(skipMonths) > 0 || len(stepUps) > 0 || len(disbursements) > 0 || len(recasts) > 0 {
+			return paramError{"conflicting", []string{"residual"}}
+		}
+		if residual >= principal {
+			return outOfRange("residual")
+		}
+	}
+
 	switch action {
 	case CalcPeriod:
 		periods = calculatePeriod()
+	case CalcPrincipal:
+		principal = getAmortizer().annuityPrincipal()
+	case CalcPayment:
+		if len(stepUps) > 0 {
+			payment, err = calculateStepPayment()
+			if err != nil {
+				return err
+			}
+			break
+		}
+		payment = getAmortizer().annuityPayment()
+	case CalcInterest:
+		interest, err = calculateInterest()
+		if err != nil {
+			return err
+		}
+	case CalcMaxPrincipal:
+		principal, err = calculateMaxPrincipal(func() Money {
+			payment = getAmortizer().annuityPayment()
+			return calculateOverpayment()
+		})
+		if err != nil {
+			return err
+		}
+		payment = getAmortizer().annuityPayment()
+	case CalcMaxPeriod:
+		periods, err = calculateMaxPeriod()
+		if err != nil {
+			return err
+		}
+		payment = getAmortizer().annuityPayment()
+	}
+
+	keepExactFigures(action)
+
+	for month := range skipMonths {
+		if month > periods {
+			return outOfRange("skip-months")
+		}
+	}
+
+	var recast []recastStep
+	if len(recasts) > 0 {
+		if recast, err = recastSteps(); err != nil {
+			return err
+		}
+	}
+
+	if isProvided("payoff-at") && (payoffAt > periods || len(skipMonths) > 0 || len(stepUps) > 0) {
+		return outOfRange("payoff-at")
+	}
+
+	schedule := annuitySchedule()
+	if action == CalcPeriod && len(skipMonths) > 0 {
+		// the skipped payments push the term out to where the schedule ends
+		periods = len(schedule)
+	}
+	overpayment := calculateOverpayment()
+	displayRatioWarning(overpayment)
+
+	if done, err := emitResult(newResult(overpayment, schedule)); done || err != nil {
+		return err
+	}
+
+	switch action {
+	case CalcPeriod:
 		displayPeriods()
+		if fractionalPeriod {
+			displayFractionalPeriod()
+		}
+		if len(skipMonths) > 0 {
+			displayLastPayment(schedule)
+		} else {
+			displayFinalPayment()
+		}
+		displayTermWarning()
 	case CalcPrincipal:
-		principal = calculatePrincipal()
 		displayPrincipal()
 	case CalcPayment:
-		payment = calculatePayment()
+		displayDrawInterest()
+		displayPayment()
+		if len(stepUps) > 0 {
+			displaySteps()
+		}
+		if residual > 0 {
+			fmt.Fprintf(stdout, msg("residual"), moneyOf(residual))
+		}
+		if paymentMayRoundDown() {
+			displayLastPayment(schedule)
+		}
+	case CalcInterest:
+		displayInterest()
+	case CalcMaxPrincipal:
+		displayPrincipal()
+		displayPayment()
+	case CalcMaxPeriod:
+		displayPeriods()
 		displayPayment()
 	}
 
+	displayStub()
+
+	if len(skipMonths) > 0 {
+		displaySkippedMonths(schedule)
+	}
+
+	if monthlyExtras() > 0 {
+		displayOutlay(moneyOf(payment))
+	}
+
 	displayOverpayment()
 
+	if fee > 0 {
+		displayTotalCost(calculateOverpayment())
+	}
+
+	if selfCheck {
+		if err := checkAnnuitySchedule(schedule, overpayment); err != nil {
+			return err
+		}
+	}
+
+	if firstInterest {
+		displayFirstInterest()
+	}
+
+	if originalPeriods >= 0 {
+		displayElapsed()
+	}
+
+	if shortenBy > 0 {
+		displayShortened()
+	}
+
+	if isProvided("payoff-at") {
+		displayPayoff(schedule)
+	}
+
+	if len(recasts) > 0 {
+		displayRecast(recast)
+	}
+
+	if interestSubsidy > 0 {
+		displaySubsidy(calculateOverpayment(), func() Money {
+			payment = getAmortizer().annuityPayment()
+			return calculateOverpayment()
+		})
+	}
+
+	if isProvided("interest-cap-percent") {
+		displayInterestCap(action, overpayment)
+	}
+
+	if extraMonthly > 0 {
+		displayExtraMonthly(schedule)
+	}
+
+	if roundPaymentUpTo > 0 {
+		displayRoundedPayment(schedule)
+	}
+
+	if verbose {
+		displayAnnuityDetails()
+		displayRoundingResidual(schedule)
+	}
+
+	if showSchedule {
+		displaySchedule(schedule)
+	}
+
+	if compactSchedule {
+		displayCompactSchedule(schedule)
+	}
+
+	if scheduleReverse {
+		displayReverseSchedule(schedule)
+	}
+
+	if interestByYear {
+		displayInterestByYear(schedule)
+	}
+
+	if scheduleByYear {
+		displayScheduleByYear(schedule)
+	}
+
+	if crossover {
+		displayCrossover(schedule)
+	}
+
+	if graph {
+		displayGraph(schedule)
+	}
+
+	if explain {
+		displayExplanation(schedule, overpayment)
+	}
+
+	if compareTo != "" {
+		if err := displayResultDiff(newResult(overpayment, schedule)); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// solveTargets lists the values each --solve target needs.
+var solveTargets = map[string]struct {
+	action CalcType
+	needs  []string
+}{
+	"payment":   {CalcPayment, []string{"principal", "periods", "interest"}},
+	"principal": {CalcPrincipal, []string{"payment", "periods", "interest"}},
+	"period":    {CalcPeriod, []string{"principal", "payment", "interest"}},
+	"interest":  {CalcInterest, []string{"principal", "payment", "periods"}},
+}
+
 func getAnnualAction() (CalcType, error) {
-	if interest < 0 {
-		return CalcInvalid, incorrectParameters()
+	if solve != "" {
+		target, ok := solveTargets[solve]
+		if !ok {
+			return CalcInvalid, outOfRange("solve")
+		}
+		if !isProvided(target.needs...) {
+			return CalcInvalid, underSpecified(target.needs...)
+		}
+		if isProvided("periods") && periods <= 0 {
+			return CalcInvalid, outOfRange("periods")
+		}
+		if target.action == CalcPeriod {
+			if err := periodInRange(); err != nil {
+				return CalcInvalid, err
+			}
+		}
+		return target.action, nil
 	}
 
 	switch true {
-	case periods < 0 && principal >= 0 && payment >= 0:
+	case isProvided("max-overpayment"):
+		if isProvided("interest", "periods") && !isProvided("principal") && !isProvided("payment") &&
+			interest > 0 && periods > 0 {
+			return CalcMaxPrincipal, nil
+		}
+		if isProvided("interest", "principal") && !isProvided("periods") && !isProvided("payment") &&
+			interest > 0 && principal > 0 {
+			return CalcMaxPeriod, nil
+		}
+		return CalcInvalid, outOfRange("max-overpayment")
+	case !isProvided("interest"):
+		if !isProvided("periods", "principal", "payment") {
+			return CalcInvalid, underSpecified("periods", "principal", "payment", "interest")
+		}
+		if periods > 0 && principal > 0 && payment > 0 {
+			return CalcInterest, nil
+		}
+		return CalcInvalid, outOfRange("periods", "principal", "payment")
+	case !isProvided("periods") && isProvided("principal", "payment"):
+		if err := periodInRange(); err != nil {
+			return CalcInvalid, err
+		}
 		return CalcPeriod, nil
-	case periods >= 0 && principal < 0 && payment >= 0:
+	case !isProvided("principal") && isProvided("periods", "payment"):
 		return CalcPrincipal, nil
-	case periods >= 0 && principal >= 0 && payment < 0:
+	case !isProvided("payment") && isProvided("periods", "principal") && periods > 0:
 		return CalcPayment, nil
+	case !isProvided("payment") && isProvided("periods", "principal"):
+		return CalcInvalid, outOfRange("periods")
+	case isProvided("payment", "principal", "periods"):
+		return CalcInvalid, paramError{"conflicting", []string{"payment", "principal", "periods", "interest"}}
 	default:
-		return CalcInvalid, incorrectParameters()
+		return CalcInvalid, underSpecified("payment", "principal", "periods")
 	}
 }
 
+// periodInRange checks the values the period is solved from: there must be
+// a principal to repay, and a payment repaying some of it.
+func periodInRange() error {
+	if principal <= 0 {
+		return outOfRange("principal")
+	}
+	if !paymentCoversInterest() {
+		return outOfRange("payment")
+	}
+
+	return nil
+}
+
+// paymentCoversInterest reports whether the payment repays some of the
+// principal in the first month, without which the loan is never repaid.
+func paymentCoversInterest() bool {
+	return payment > 0 && payment > principal*getInterestRate()
+}
+
 func calculatePeriod() int {
+	return int(math.Ceil(calculateFractionalPeriod()))
+}
+
+// calculateFractionalPeriod is the number of payments before it's rounded
+// up to a whole one.
+func calculateFractionalPeriod() float64 {
+	i := getInterestRate()
+	if i == 0 {
+		return principal / payment
+	}
+
+	return math.Log(payment/(payment-i*principal)) / math.Log(1+i)
+}
+
+// calculateFinalPayment returns what is left to pay in the last month,
+// after periods-1 full payments.
+func calculateFinalPayment() float64 {
+	i := getInterestRate()
+
+	return roundUp(remainingBalance(periods-1) * (1 + i))
+}
+
+func remainingBalance(k int) float64 {
+	i := getInterestRate()
+	if i == 0 {
+		return principal - payment*float64(k)
+	}
+
+	nk := math.Pow(1+i, float64(k))
+
+	return principal*nk - payment*(nk-1)/i
+}
+
+func validDisbursements() bool {
+	var total float64
+
+	for _, d := range disbursements {
+		if d.value <= 0 {
+			return false
+		}
+		total += d.value
+	}
+
+	return math.Round(total*100) == math.Round(principal*100)
+}
+
+// calculateDrawInterest sums the interest-only payments made on the
+// disbursed balance until the last tranche, after which amortization of
+// the full principal begins.
+func calculateDrawInterest() float64 {
+	var balance, total float64
+
+	if len(disbursements) == 0 {
+		return 0
+	}
+
 	i := getInterestRate()
-	n := math.Log(payment/(payment-i*principal)) / math.Log(1+i)
+	next := 0
+
+	for m := 1; m <= disbursements[len(disbursements)-1].month; m++ {
+		for ; next < len(disbursements) && disbursements[next].month == m; next++ {
+			balance += disbursements[next].value
+		}
 
-	return int(math.Ceil(n))
+		total += roundUp(balance * i)
+	}
+
+	return total
+}
+
+// calculateMaxPrincipal bisects over whole principals for the largest one
+// whose overpayment, as computed by the given function, is within
+// maxOverpayment.
+func calculateMaxPrincipal(overpayment func() Money) (float64, error) {
+	limit := moneyOf(maxOverpayment)
+	lo, hi := 0.0, 1.0
+
+	for principal = hi; overpayment() <= limit && hi < 1e15; principal = hi {
+		lo, hi = hi, hi*2
+	}
+
+	for k := 0; hi-lo > 1; k++ {
+		if k == solverMaxIter {
+			return 0, solverFailed(hi - lo)
+		}
+
+		principal = math.Floor((lo + hi) / 2)
+		if overpayment() <= limit {
+			lo = principal
+		} else {
+			hi = principal
+		}
+	}
+
+	return lo, nil
+}
+
+// calculateMaxPeriod returns the longest term whose overpayment is within
+// maxOverpayment, the overpayment growing with the term.
+func calculateMaxPeriod() (int, error) {
+	limit := moneyOf(maxOverpayment)
+	n := 0
+
+	for periods = 1; periods <= maxScheduleMonths; periods++ {
+		payment = getAmortizer().annuityPayment()
+		if calculateOverpayment() > limit {
+			break
+		}
+		n = periods
+	}
+
+	if n == 0 {
+		return 0, fmt.Errorf(msg("no-max-period"), limit)
+	}
+
+	return n, nil
+}
+
+func calculateOverpayment() Money {
+	total := moneyOf(roundUp(payment * float64(periods))).Add(stubPaid())
+	if overpaymentFromSchedule() {
+		total, _ = scheduleTotals(annuitySchedule())
+	}
+
+	// the residual is never repaid, so none of it counts as paid back
+	return total.Sub(loanPrincipal()).Add(moneyOf(residual)).Add(moneyOf(calculateDrawInterest()))
+}
+
+// overpaymentFromSchedule reports whether the overpayment is what the
+// schedule pays rather than every one of the periods paid in full.
+func overpaymentFromSchedule() bool {
+	return len(skipMonths) > 0 || paymentMayRoundDown() || len(stepUps) > 0 || reduceFinal
 }
 
 func calculatePrincipal() float64 {
 	i := getInterestRate()
-	ni := math.Pow(1+i, float64(periods))
-	p := payment * (ni - 1) / (i * ni)
+	if i == 0 {
+		return roundDown(payment * float64(periods))
+	}
+
+	growth := compoundGrowth(i, periods)
+	p := payment * growth / (i * (1 + growth))
+
+	return roundDown(p)
+}
 
-	return math.Floor(p)
+// compoundGrowth is (1+i)^n - 1, computed without the cancellation of
+// subtracting 1 from a power close to it, which at low rates and short terms
+// cost large loans more than a cent.
+func compoundGrowth(i float64, n int) float64 {
+	return math.Expm1(float64(n) * math.Log1p(i))
 }
 
 func calculatePayment() float64 {
 	i := getInterestRate()
-	ni := math.Pow(1+i, float64(periods))
-	a := principal * i * ni / (ni - 1)
+	if i == 0 {
+		return roundPayment((principal - residual) / float64(periods))
+	}
 
-	return math.Ceil(a)
+	growth := compoundGrowth(i, periods)
+	a := (principal*(1+growth) - residual) * i / growth
+
+	return roundPayment(a)
+}
+
+// calculateInterest finds the annual interest rate by bisection, since the
+// annuity formula can't be solved for the rate directly.
+func calculateInterest() (float64, error) {
+	// a total below the principal would need a negative rate
+	if payment*float64(periods) < principal {
+		return 0, outOfRange("payment")
+	}
+
+	i, err := bisect(0, 1, 0, func(i float64) float64 {
+		ni := math.Pow(1+i, float64(periods))
+		return principal*i*ni/(ni-1) - payment
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return getAnnualRate(i), nil
+}
+
+// bisect finds the root of an increasing f between lo and hi, within
+// -solver-tolerance and -solver-max-iter, or as soon as the interval is
+// narrower than width, which for an amount needn't be finer than a cent.
+func bisect(lo, hi, width float64, f func(x float64) float64) (float64, error) {
+	var residual float64
+
+	for k := 0; k < solverMaxIter; k++ {
+		x := (lo + hi) / 2
+
+		residual = f(x)
+		if math.Abs(residual) <= solverTolerance || hi-lo < width {
+			return x, nil
+		}
+
+		if residual > 0 {
+			hi = x
+		} else {
+			lo = x
+		}
+	}
+
+	return 0, solverFailed(residual)
+}
+
+func solverFailed(residual float64) error {
+	return fmt.Errorf(msg("solver-failed"), solverMaxIter, residual)
 }
 
 func displayPeriods() {
+	fmt.Fprintf(stdout, msg("period"), formatPeriods(periods))
+}
+
+// displayFirstInterest splits the first payment the way the schedule does.
+func displayFirstInterest() {
+	rows := getAmortizer().annuitySchedule(moneyOf(payment))
+	if len(rows) == 0 {
+		return
+	}
+
+	fmt.Fprintf(stdout, msg("first-interest"), rows[0].Payment, rows[0].InterestPortion, rows[0].PrincipalPortion)
+}
+
+// displayShortened shows how much more the payment must be to repay the
+// loan -shorten-by-years sooner, and the interest that saves.
+func displayShortened() {
+	savedPayment, savedPeriods := payment, periods
+	defer func() { payment, periods = savedPayment, savedPeriods }()
+
+	overpayment := calculateOverpayment()
+
+	periods -= shortenBy * paymentsPerYear
+	payment = getAmortizer().annuityPayment()
+
+	fmt.Fprintf(stdout, msg("shortened"), shortenBy, moneyOf(payment), moneyOf(payment).Sub(moneyOf(savedPayment)),
+		overpayment.Sub(calculateOverpayment()))
+}
+
+// displayElapsed shows how far into the loan the borrower is when -periods
+// counts the payments left of -original-periods.
+func displayElapsed() {
+	made := originalPeriods - periods
+	fmt.Fprintf(stdout, msg("elapsed"), made, originalPeriods, float64(made)/float64(originalPeriods)*100)
+}
+
+func displayFractionalPeriod() {
+	months := calculateFractionalPeriod() * 12 / float64(paymentsPerYear)
+	fmt.Fprintf(stdout, msg("fractional-period"), months)
+}
+
+func formatPeriods(n int) string {
 	var dates = make([]string, 0, 2)
 
-	years := periods / 12
-	months := periods % 12
+	n = paymentMonths(n)
+	years := n / 12
+	months := n % 12
 
 	if years > 1 {
-		dates = append(dates, fmt.Sprintf("%d years", years))
+		dates = append(dates, fmt.Sprintf(msg("years"), years))
 	} else if years == 1 {
-		dates = append(dates, "1 year")
+		dates = append(dates, msg("year"))
 	}
 
 	if months > 1 {
-		dates = append(dates, fmt.Sprintf("%d months", months))
+		dates = append(dates, fmt.Sprintf(msg("months"), months))
 	} else if months == 1 {
-		dates = append(dates, "1 month")
+		dates = append(dates, msg("month"))
+	}
+
+	return strings.Join(dates, msg("and"))
+}
+
+func displayFinalPayment() {
+	final := calculateFinalPayment()
+	if final >= payment {
+		return
+	}
+
+	fmt.Fprintf(stdout, msg("final-payment"), moneyOf(ceilAmount(final)), moneyOf(ceilAmount(payment)))
+}
+
+func displayDrawInterest() {
+	if len(disbursements) == 0 {
+		return
 	}
 
-	fmt.Printf("It will take %s to repay this loan!\n", strings.Join(dates, " and "))
+	last := disbursements[len(disbursements)-1].month
+	fmt.Fprintf(stdout, msg("draw-interest"), last, moneyOf(calculateDrawInterest()))
+}
+
+func displayTermWarning() {
+	if periods > warnTerm {
+		fmt.Fprintf(stderr, msg("warn-term"), periods, warnTerm)
+	}
+}
+
+// displayRatioWarning warns on stderr, so that it shows with any -format,
+// when the overpayment exceeds -warn-overpayment-ratio times the principal.
+func displayRatioWarning(overpayment Money) {
+	if warnRatio <= 0 || loanPrincipal() <= 0 {
+		return
+	}
+
+	if ratio := overpayment.Float64() / loanPrincipal().Float64(); ratio > warnRatio {
+		fmt.Fprintf(stderr, msg("warn-ratio"), overpayment, ratio, warnRatio)
+	}
 }
 
 func displayPrincipal() {
-	fmt.Printf("Your loan principal = %d!\n", int(principal))
+	fmt.Fprintf(stdout, msg("principal"), withExact(moneyOf(floorAmount(principal)), exactPrincipal))
 }
 
 func displayPayment() {
-	fmt.Printf("Your annuity payment = %d!\n", int(payment))
+	fmt.Fprintf(stdout, msg("payment"), withExact(moneyOf(ceilAmount(payment)), exactPayment))
+}
+
+func displayInterest() {
+	scale := math.Pow(10, float64(ratePrecision))
+	rate := math.Round(interest*scale) / scale
+
+	fmt.Fprintf(stdout, msg("interest"), ratePrecision, rate)
+}
+
+// monthlyExtras is what the borrower pays every month on top of the loan,
+// leaving the amortization itself untouched.
+func monthlyExtras() Money {
+	return moneyOf(monthlyTax).Add(moneyOf(monthlyInsurance))
+}
+
+func displayOutlay(paid Money) {
+	fmt.Fprintf(stdout, msg("outlay"), paid.Add(monthlyExtras()), paid, moneyOf(monthlyTax), moneyOf(monthlyInsurance))
+}
+
+func displayAnnuityDetails() {
+	if paymentsPerYear == 12 {
+		fmt.Fprintf(stdout, msg("monthly-rate"), getInterestRate()*100)
+	} else {
+		fmt.Fprintf(stdout, msg("periodic-rate"), getInterestRate()*100)
+	}
+
+	if principal > 0 {
+		fmt.Fprintf(stdout, msg("factor"), ceilAmount(payment)/principal*1000)
+		fmt.Fprintf(stdout, msg("loan-constant"), ceilAmount(payment)*float64(paymentsPerYear)/principal*100)
+	}
+
+	// the interest per 1000 of principal and year, to compare loans of
+	// different sizes and terms
+	if principal > 0 && periods > 0 {
+		years := float64(periods) / float64(paymentsPerYear)
+		fmt.Fprintf(stdout, msg("cost-per-1000"), calculateOverpayment().Float64()/principal*1000/years)
+	}
 }
 
 func displayOverpayment() {
-	fmt.Printf("Overpayment = %d\n", int(math.Ceil(payment*float64(periods))-principal))
+	overpayment := calculateOverpayment()
+	if displayRounding {
+		overpayment = overpayment.Ceil()
+	}
+
+	if showExact {
+		fmt.Fprintf(stdout, msg("overpayment"), withExact(overpayment, exactOverpayment()))
+		return
+	}
+
+	fmt.Fprintf(stdout, msg("overpayment"), overpayment)
+}
+
+// displayTotalCost adds -fee to the overpayment, which already includes
+// the interest on a capitalized fee.
+func displayTotalCost(overpayment Money) {
+	key := "total-cost-upfront"
+	if capitalizeFees {
+		key = "total-cost-capitalized"
+	}
+
+	fmt.Fprintf(stdout, msg(key), overpayment.Add(moneyOf(fee)), moneyOf(fee))
+}
+
+func doInterestOnlyCalculations() error {
+	if !isProvided("principal", "interest", "periods") {
+		return underSpecified("principal", "interest", "periods")
+	}
+	if periods <= 0 {
+		return outOfRange("periods")
+	}
+
+	if interestOnlyMonths > 0 {
+		if interestOnly {
+			return paramError{"conflicting", []string{"interest-only", "interest-only-months"}}
+		}
+		return doInterestOnlyPhase()
+	}
+
+	payment = roundUp(principal * getInterestRate())
+	overpayment := moneyOf(payment * float64(periods))
+
+	if done, err := emitResult(newResult(overpayment, nil)); done || err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, msg("interest-only"), moneyOf(ceilAmount(payment)))
+	fmt.Fprintf(stdout, msg("principal-due"), moneyOf(principal))
+	fmt.Fprintf(stdout, msg("overpayment"), overpayment)
+
+	return nil
 }
 
 func doDiffCalculations() error {
-	// check input values
-	if principal < 0 || interest < 0 || periods < 0 {
-		return incorrectParameters()
+	if solve != "" && solve != "payment" {
+		return outOfRange("solve")
 	}
 
-	var total float64
+	if isProvided("max-overpayment") {
+		// solve for the principal instead
+		if isProvided("principal") {
+			return paramError{"conflicting", []string{"principal", "max-overpayment"}}
+		}
+		if !isProvided("interest", "periods") {
+			return underSpecified("interest", "periods")
+		}
+		if interest <= 0 || periods <= 0 {
+			return outOfRange("interest", "periods")
+		}
+
+		var err error
+
+		principal, err = calculateMaxPrincipal(calculateDiffOverpayment)
+		if err != nil {
+			return err
+		}
+		keepExactFigures(CalcInvalid)
+		displayPrincipal()
+	} else if !isProvided("principal", "interest", "periods") {
+		// check input values
+		return underSpecified("principal", "interest", "periods")
+	} else if periods == 0 {
+		// the principal is split evenly over the periods
+		return errors.New(msg("no-periods"))
+	}
+
+	payments := getAmortizer().diffPayments()
+	schedule := diffSchedule(payments)
+	overpayment := diffOverpayment(payments)
+	displayRatioWarning(overpayment)
+
+	if done, err := emitResult(newResult(overpayment, schedule)); done || err != nil {
+		return err
+	}
+
+	for _, row := range schedule {
+		paid := row.Payment.Ceil()
+
+		if monthlyExtras() > 0 {
+			fmt.Fprintf(stdout, msg("diff-outlay"), row.Month, paid, paid.Add(monthlyExtras()))
+		} else {
+			fmt.Fprintf(stdout, msg("diff-payment"), row.Month, paid)
+		}
+	}
+
+	fmt.Fprintln(stdout)
+	fmt.Fprintf(stdout, msg("overpayment"), overpayment)
+
+	if diffFixedInterest {
+		displayFixedInterest(overpayment)
+	}
+
+	if fee > 0 {
+		displayTotalCost(overpayment)
+	}
+
+	if originalPeriods >= 0 {
+		displayElapsed()
+	}
+
+	if interestSubsidy > 0 {
+		displaySubsidy(overpayment, calculateDiffOverpayment)
+	}
+
+	if validateSum {
+		if err := reconcileSchedule(schedule, overpayment); err != nil {
+			return err
+		}
+	}
+
+	if showSchedule {
+		displaySchedule(schedule)
+	}
+
+	if compactSchedule {
+		displayCompactSchedule(schedule)
+	}
+
+	if scheduleReverse {
+		displayReverseSchedule(schedule)
+	}
+
+	if interestByYear {
+		displayInterestByYear(schedule)
+	}
+
+	if scheduleByYear {
+		displayScheduleByYear(schedule)
+	}
+
+	if crossover {
+		displayCrossover(schedule)
+	}
+
+	if graph {
+		displayGraph(schedule)
+	}
+
+	if explain {
+		displayExplanation(schedule, overpayment)
+	}
+
+	if compareTo != "" {
+		if err := displayResultDiff(newResult(overpayment, schedule)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// displayFixedInterest labels the -diff-fixed-interest overpayment and
+// compares it with the one on the declining balance.
+func displayFixedInterest(overpayment Money) {
+	diffFixedInterest = false
+	declining := calculateDiffOverpayment()
+	diffFixedInterest = true
+
+	fmt.Fprintf(stdout, msg("fixed-interest"), declining, overpayment.Sub(declining))
+}
+
+func calculateDiffOverpayment() Money {
+	return diffOverpayment(getAmortizer().diffPayments())
+}
+
+func diffOverpayment(payments []float64) Money {
+	var total Money
+
+	for _, dp := range payments {
+		total = total.Add(moneyOf(dp))
+	}
+
+	return total.Add(stubPaid()).Sub(loanPrincipal()).Ceil()
+}
+
+func calculateDiffPayments() []float64 {
+	var payments = make([]float64, 0, scheduleCapacity())
 
 	// do temporary calculations
-	pn := principal / float64(periods)
+	n := float64(periods)
+	pn := principal / n
 	i := getInterestRate()
 
 	for m := 1; m <= periods; m++ {
-		dp := math.Ceil(pn + i*(principal-pn*(float64(m)-1)))
-		total += dp
+		// multiplying before dividing keeps the balance exact whenever the
+		// principal divides evenly by the periods
+		balance := principal * (n - float64(m-1)) / n
+		if diffFixedInterest {
+			balance = principal
+		}
+		payments = append(payments, roundByPolicy(pn+i*balance, roundUp))
+	}
 
-		fmt.Printf("Month %d: payment is %d\n", m, int(dp))
+	return payments
+}
+
+// roundUp and roundDown apply the whole-unit rounding of computed figures,
+// unless --round-display-only leaves that to the display functions or
+// --round=none drops it.
+func roundUp(v float64) float64 {
+	if displayRounding || roundPolicy == "none" {
+		return v
 	}
 
-	fmt.Printf("\nOverpayment = %d\n", int(math.Ceil(total-principal)))
+	return ceilAmount(v)
+}
 
-	return nil
+func roundDown(v float64) float64 {
+	if displayRounding || roundPolicy == "none" {
+		return v
+	}
+
+	return floorAmount(v)
+}
+
+// roundPayment rounds the annuity payment by -round, or in favor of -favor.
+func roundPayment(v float64) float64 {
+	if favor == "borrower" {
+		return roundByPolicy(v, roundDown)
+	}
+
+	return roundByPolicy(v, roundUp)
 }
 
 func getInterestRate() float64 {
-	return interest / (12 * 100)
+	return EffectivePeriodicRate(interest-interestSubsidy, compoundingPerYear, paymentsPerYear)
+}
+
+// displayEffectiveRate shows the periodic rate an -interest-is-ear rate
+// comes to.
+func displayEffectiveRate() {
+	fmt.Fprintf(stdout, msg("explain-ear"), interest-interestSubsidy, getInterestRate()*100)
+}
+
+// getAnnualRate is the inverse of getInterestRate.
+func getAnnualRate(i float64) float64 {
+	return nominalAnnualRate(i, compoundingPerYear, paymentsPerYear) + interestSubsidy
+}
+
+// displaySubsidy compares the subsidized overpayment with the one computed
+// by the given function at the full rate.
+func displaySubsidy(subsidized Money, overpayment func() Money) {
+	saved, subsidy := payment, interestSubsidy
+
+	interestSubsidy = 0
+	full := overpayment()
+	payment, interestSubsidy = saved, subsidy
+
+	fmt.Fprintf(stdout, msg("subsidy"), interestSubsidy, full, full.Sub(subsidized))
 }
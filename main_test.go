@@ -0,0 +1,925 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files from the current output")
+
+// goldenCases are the recorded command lines whose output is kept under
+// testdata/golden, a file each.
+var goldenCases = []struct {
+	name string
+	args string
+}{
+	{"annuity-payment", "--type=annuity --principal=1000000 --periods=60 --interest=10"},
+	{"annuity-period", "--type=annuity --principal=500000 --payment=23000 --interest=7.8"},
+	{"annuity-period-years", "--type=annuity --principal=1000000 --payment=15000 --interest=10"},
+	{"annuity-principal", "--type=annuity --payment=8721.8 --periods=120 --interest=5.6"},
+	{"annuity-interest", "--type=annuity --principal=1000000 --payment=21248 --periods=60"},
+	{"annuity-schedule", "--type=annuity --principal=1000 --periods=6 --interest=12 --schedule"},
+	{"annuity-json", "--type=annuity --principal=1000 --periods=3 --interest=12 --format=json"},
+	{"diff", "--type=diff --principal=1000000 --periods=10 --interest=10"},
+	{"diff-json", "--type=diff --principal=500000 --periods=3 --interest=7.8 --format=json"},
+	{"error-no-type", "--principal=1000000 --periods=60 --interest=10"},
+	{"error-diff-payment", "--type=diff --principal=1000000 --payment=104000 --periods=8"},
+	{"error-negative", "--type=annuity --principal=-500000 --periods=8 --interest=7.8"},
+	{"error-too-few", "--type=annuity --principal=1000000 --periods=60"},
+	{"error-json", "--type=annuity --principal=1000000 --format=json"},
+	{"error-low-payment", "--type=annuity --payment=5 --principal=1000 --interest=10"},
+	{"error-unknown-format", "--type=annuity --principal=1000 --periods=6 --interest=12 --format=xml"},
+}
+
+// runArgs runs the command line with "today" pinned, returning what it
+// wrote and its exit code.
+func runArgs(t *testing.T, args string) (string, string, int) {
+	t.Helper()
+
+	return runArgv(t, strings.Fields(args)...)
+}
+
+// runArgv is runArgs for arguments which may contain spaces.
+func runArgv(t *testing.T, args ...string) (string, string, int) {
+	t.Helper()
+
+	saved := now
+	defer func() { now = saved }()
+	now = func() time.Time { return time.Date(2024, time.January, 15, 12, 0, 0, 0, time.UTC) }
+
+	var out, errOut bytes.Buffer
+	code := run(args, &out, &errOut)
+
+	return out.String(), errOut.String(), code
+}
+
+func TestGolden(t *testing.T) {
+	for _, c := range goldenCases {
+		t.Run(c.name, func(t *testing.T) {
+			out, errOut, code := runArgs(t, c.args)
+			got := fmt.Sprintf("$ %s\nexit %d\n-- stdout --\n%s-- stderr --\n%s", c.args, code, out, errOut)
+
+			path := filepath.Join("testdata", "golden", c.name+".golden")
+			if *update {
+				if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("%v; run with -update to record it", err)
+			}
+
+			if got != string(want) {
+				t.Errorf("output differs from %s:\ngot:\n%s\nwant:\n%s", path, got, want)
+			}
+		})
+	}
+}
+
+// TestFinalPaymentCovers solves for the term and checks that n-1 payments
+// and the final one rounded up cover the principal and the interest the
+// schedule charges, by less than the unit it's rounded to.
+func TestFinalPaymentCovers(t *testing.T) {
+	for _, args := range []string{
+		"--type=annuity --principal=500000 --payment=23000 --interest=7.8",
+		"--type=annuity --principal=1000000 --payment=15000 --interest=10",
+		"--type=annuity --principal=1000 --payment=300 --interest=0",
+		"--type=annuity --principal=123456 --payment=1000 --interest=3.5",
+	} {
+		parseFlags(t, args)
+		periods = calculatePeriod()
+
+		final := moneyOf(calculateFinalPayment())
+		if final > moneyOf(payment) {
+			t.Errorf("%s: final payment %s over %s", args, final, moneyOf(payment))
+		}
+
+		_, interest := scheduleTotals(annuitySchedule())
+		paid := moneyOf(payment).Mul(float64(periods - 1)).Add(final)
+		if over := paid.Sub(moneyOf(principal).Add(interest)); over < 0 || over >= moneyUnit() {
+			t.Errorf("%s: %d months pay %s, %s over the principal and interest", args, periods, paid, over)
+		}
+	}
+}
+
+// TestDisbursements compares a construction loan drawn in three tranches
+// with a loan of the same total paid out at once: the amortization is the
+// same, plus the interest on the balance drawn so far until the last one.
+func TestDisbursements(t *testing.T) {
+	single, _, _ := runArgs(t, "--type=annuity --principal=300000 --periods=240 --interest=6")
+	drawn, _, _ := runArgs(t, "--type=annuity --principal=300000 --periods=240 --interest=6 --disbursements=1:100000,4:100000,7:100000")
+
+	// 3 months of 500, 3 of 1000 and 1 of 1500, less than the 7*1500
+	// of drawing it all in the first month
+	want := "Interest during the 7-month draw period = 6000\n" +
+		"Your annuity payment = 2150!\nOverpayment = 222000\n"
+	if single != "Your annuity payment = 2150!\nOverpayment = 216000\n" || drawn != want {
+		t.Errorf("single:\n%sdrawn:\n%s", single, drawn)
+	}
+
+	if out, _, _ := runArgs(t, "--type=annuity --principal=300000 --periods=240 --interest=6 --disbursements=1:100000,4:100000"); out != "Incorrect parameters\n" {
+		t.Errorf("tranches short of the principal: %q", out)
+	}
+
+	if code, fields := jsonError(t, "--type=annuity --principal=300000 --periods=240 --interest=6 --disbursements=1:100000,4:100000"); code != "out-of-range" || fmt.Sprint(fields) != "[disbursements]" {
+		t.Errorf("tranches short of the principal: %s %v", code, fields)
+	}
+}
+
+// TestPrincipalForOverpayment checks the principal solved for from a
+// target overpayment against the overpayment computed forward from it, and
+// from a unit more.
+func TestPrincipalForOverpayment(t *testing.T) {
+	for _, c := range []struct {
+		terms  string
+		target float64
+	}{
+		{"--periods=120 --interest=5", 27000},
+		{"--periods=360 --interest=6.5", 100000},
+		{"--periods=12 --interest=12", 500},
+		{"--periods=60 --interest=0.1", 10},
+	} {
+		out, _, _ := runArgs(t, fmt.Sprintf("--type=annuity %s --max-overpayment=%g", c.terms, c.target))
+
+		var p, overpayment float64
+		if _, err := fmt.Sscanf(out, "Your loan principal = %g!", &p); err != nil {
+			t.Fatalf("%s: %v in %q", c.terms, err, out)
+		}
+
+		for _, principal := range []float64{p, p + 1} {
+			forward, _, _ := runArgs(t, fmt.Sprintf("--type=annuity %s --principal=%g", c.terms, principal))
+			if _, err := fmt.Sscanf(forward[strings.Index(forward, "Overpayment"):], "Overpayment = %g", &overpayment); err != nil {
+				t.Fatalf("%s: %v in %q", c.terms, err, forward)
+			}
+
+			if within := overpayment <= c.target; within != (principal == p) {
+				t.Errorf("%s: a principal of %g overpays %g against %g", c.terms, principal, overpayment, c.target)
+			}
+		}
+	}
+}
+
+func TestInterestOnly(t *testing.T) {
+	for _, c := range []struct{ args, want string }{
+		{"--principal=100000 --periods=12 --interest=6",
+			"Your interest-only payment = 500!\nThe principal of 100000 is due with the last payment\nOverpayment = 6000\n"},
+		// 583.33 rounds up, the interest counting the rounded payments
+		{"--principal=100000 --periods=12 --interest=7",
+			"Your interest-only payment = 584!\nThe principal of 100000 is due with the last payment\nOverpayment = 7008\n"},
+		{"--cents --principal=10000000 --periods=12 --interest=7",
+			"Your interest-only payment = 58334!\nThe principal of 10000000 is due with the last payment\nOverpayment = 700008\n"},
+		{"--principal=100000 --periods=0 --interest=6", "Incorrect parameters\n"},
+	} {
+		if out, _, _ := runArgs(t, "--interest-only "+c.args); out != c.want {
+			t.Errorf("%s:\n%s", c.args, out)
+		}
+	}
+}
+
+func TestYears(t *testing.T) {
+	for _, loan := range []string{
+		"--type=annuity --principal=1000000 --interest=10",
+		"--type=annuity --payment=8721.8 --interest=5.6",
+		"--type=diff --principal=500000 --interest=7.8",
+	} {
+		years, _, _ := runArgs(t, loan+" --years=5")
+		months, _, _ := runArgs(t, loan+" --periods=60")
+		if years != months || strings.HasPrefix(years, "Incorrect") {
+			t.Errorf("%s: --years=5:\n%s--periods=60:\n%s", loan, years, months)
+		}
+	}
+
+	if out, _, _ := runArgs(t, "--type=annuity --principal=1000000 --interest=10 --years=5 --periods=60"); out != "Incorrect parameters\n" {
+		t.Errorf("both --years and --periods: %q", out)
+	}
+}
+
+// TestExtraMonthly compares the term and interest reported for an extra
+// payment with those of a loan paying the larger amount from the start.
+func TestExtraMonthly(t *testing.T) {
+	scheduled := func(args string) (int, Money) {
+		out, _, _ := runArgs(t, args+" --format=json")
+
+		var r Result
+		if err := json.Unmarshal([]byte(out), &r); err != nil || r.ScheduledOverpayment == nil {
+			t.Fatalf("%s: %v in %s", args, err, out)
+		}
+
+		return len(r.Schedule), *r.ScheduledOverpayment
+	}
+
+	months, interest := scheduled("--type=annuity --principal=100000 --periods=120 --interest=6")
+	sooner, less := scheduled("--type=annuity --principal=100000 --payment=1311 --interest=6")
+	if months != 120 || sooner != 97 {
+		t.Fatalf("%d months, %d with the extra payment", months, sooner)
+	}
+
+	out, _, _ := runArgs(t, "--type=annuity --principal=100000 --periods=120 --interest=6 --extra-monthly=200")
+	want := fmt.Sprintf("With 200 extra per month it will take %s, saving %s of interest\n", formatPeriods(sooner), interest.Sub(less))
+	if !strings.HasSuffix(out, want) {
+		t.Errorf("%q, want %q", out, want)
+	}
+
+	if out, _, _ := runArgs(t, "--type=annuity --principal=100000 --periods=120 --interest=6 --extra-monthly=0"); strings.Contains(out, "extra") {
+		t.Errorf("no extra payment: %q", out)
+	}
+}
+
+// TestMonthlyExtras checks that the taxes and insurance add to the outlay
+// but leave the payment and the overpayment alone.
+func TestMonthlyExtras(t *testing.T) {
+	for _, c := range []struct{ loan, want string }{
+		{"--type=annuity --principal=100000 --periods=120 --interest=6",
+			"Total monthly outlay = 1311.50 (payment 1111 + tax 150 + insurance 50.50)\n"},
+		{"--type=diff --principal=1000 --periods=3 --interest=12",
+			"Month 1: payment is 344, total outlay is 544.50\n"},
+	} {
+		plain, _, _ := runArgs(t, c.loan)
+		extras, _, _ := runArgs(t, c.loan+" --monthly-tax=150 --monthly-insurance=50.5")
+
+		if !strings.Contains(extras, c.want) {
+			t.Errorf("%s: no %q in\n%s", c.loan, c.want, extras)
+		}
+
+		overpayment := plain[strings.Index(plain, "Overpayment"):]
+		if !strings.HasSuffix(extras, overpayment) {
+			t.Errorf("%s: the overpayment changed:\n%s", c.loan, extras)
+		}
+	}
+}
+
+// TestRoundDisplayOnly shows the difference --round-display-only makes:
+// the overpayment counts the unrounded payment, so it no longer includes
+// what rounding up each payment adds, while the printed payment and term
+// stay the same.
+func TestRoundDisplayOnly(t *testing.T) {
+	for _, c := range []struct{ loan, rounded, unrounded string }{
+		{"--type=annuity --principal=1000000 --periods=60 --interest=10",
+			"Your annuity payment = 21248!\nOverpayment = 274880\n",
+			"Your annuity payment = 21248!\nOverpayment = 274823\n"},
+		// the term and final payment don't depend on rounding the given payment
+		{"--type=annuity --principal=1000 --payment=300 --interest=10",
+			"It will take 4 months to repay this loan!\nFinal payment will be 119 instead of 300\nOverpayment = 200\n",
+			"It will take 4 months to repay this loan!\nFinal payment will be 119 instead of 300\nOverpayment = 200\n"},
+	} {
+		rounded, _, _ := runArgs(t, c.loan)
+		unrounded, _, _ := runArgs(t, c.loan+" --round-display-only")
+		if rounded != c.rounded || unrounded != c.unrounded {
+			t.Errorf("%s:\n%s--round-display-only:\n%s", c.loan, rounded, unrounded)
+		}
+	}
+
+	rounded, _, _ := runArgs(t, "--type=diff --principal=1000000 --periods=10 --interest=10")
+	unrounded, _, _ := runArgs(t, "--type=diff --principal=1000000 --periods=10 --interest=10 --round-display-only")
+	if !strings.HasSuffix(rounded, "\nOverpayment = 45837\n") || !strings.HasSuffix(unrounded, "\nOverpayment = 45834\n") {
+		t.Errorf("diff:\n%s--round-display-only:\n%s", rounded, unrounded)
+	}
+	if strings.Split(rounded, "\n")[0] != strings.Split(unrounded, "\n")[0] {
+		t.Errorf("the first diff payment differs:\n%s\n%s", rounded, unrounded)
+	}
+}
+
+// jsonError returns the code and fields of the JSON error of the command
+// line, or an empty code if it succeeded.
+func jsonError(t *testing.T, args string) (string, []string) {
+	t.Helper()
+
+	out, _, _ := runArgs(t, args+" --format=json")
+
+	var r struct {
+		Error *struct {
+			Code   string   `json:"code"`
+			Fields []string `json:"fields"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(out), &r); err != nil {
+		t.Fatalf("%s: %v in %s", args, err, out)
+	}
+
+	if r.Error == nil {
+		return "", nil
+	}
+
+	return r.Error.Code, r.Error.Fields
+}
+
+// TestNegativeValues tells an omitted value, which is solved for, from an
+// explicitly negative one, which is an error naming it, the -1 the flags
+// default to included.
+func TestNegativeValues(t *testing.T) {
+	given := map[string]string{"principal": "1000", "payment": "100", "periods": "12", "interest": "5"}
+
+	for name := range given {
+		var loan []string
+		for other, value := range given {
+			if other != name {
+				loan = append(loan, "--"+other+"="+value)
+			}
+		}
+		args := "--type=annuity " + strings.Join(loan, " ")
+
+		if code, fields := jsonError(t, args); code != "" {
+			t.Errorf("omitted %s: %s %v", name, code, fields)
+		}
+
+		for _, negative := range []string{"-1", "-500"} {
+			code, fields := jsonError(t, args+" --"+name+"="+negative)
+			if code != "out-of-range" || strings.Join(fields, ",") != name {
+				t.Errorf("--%s=%s: %s %v", name, negative, code, fields)
+			}
+		}
+	}
+
+	if out, _, _ := runArgs(t, "--type=diff --principal=-1000 --periods=12 --interest=5"); out != "Incorrect parameters\n" {
+		t.Errorf("a negative diff principal: %q", out)
+	}
+}
+
+// TestProvidedZero checks that a zero given for a value is taken as given
+// rather than as the value to solve for.
+func TestProvidedZero(t *testing.T) {
+	for _, c := range []struct{ args, want string }{
+		{"--principal=1000 --periods=12 --interest=0", "Your annuity payment = 84!\nOverpayment = 8\n"},
+		{"--principal=1000 --periods=12 --payment=84", "Your annual interest rate = 1.47%!\nOverpayment = 8\n"},
+		{"--payment=0 --periods=12 --interest=5", "Your loan principal = 0!\nOverpayment = 0\n"},
+		{"--principal=0 --periods=12 --interest=5", "Your annuity payment = 0!\nOverpayment = 0\n"},
+		{"--principal=1000 --periods=0 --interest=5", "Incorrect parameters\n"},
+		// nothing to repay, so no period to solve for
+		{"--principal=0 --payment=100 --interest=5", "Incorrect parameters\n"},
+		{"--principal=1000 --payment=0 --interest=5", "Incorrect parameters\n"},
+	} {
+		if out, _, _ := runArgs(t, "--type=annuity "+c.args); out != c.want {
+			t.Errorf("%s: %q, want %q", c.args, out, c.want)
+		}
+	}
+
+	for _, c := range []struct {
+		args   string
+		fields string
+	}{
+		{"--principal=1000 --periods=12 --interest=5 --payment=0", "payment,principal,periods,interest"},
+		{"--principal=0 --payment=100 --interest=5", "principal"},
+		{"--principal=1000 --payment=0 --interest=5", "payment"},
+		{"--principal=1000 --periods=0 --interest=5", "periods"},
+	} {
+		if _, fields := jsonError(t, "--type=annuity "+c.args); strings.Join(fields, ",") != c.fields {
+			t.Errorf("%s: fields %v, want %s", c.args, fields, c.fields)
+		}
+	}
+}
+
+// TestSolve checks that each -solve target needs the other three values,
+// naming the one missing, and then calculates what it names even with
+// all four given.
+func TestSolve(t *testing.T) {
+	values := map[string]string{"principal": "1000", "payment": "100", "periods": "12", "interest": "5"}
+	want := map[string]CalcType{"payment": CalcPayment, "principal": CalcPrincipal, "period": CalcPeriod, "interest": CalcInterest}
+
+	for target, action := range want {
+		needs := solveTargets[target].needs
+
+		var all []string
+		for name, value := range values {
+			all = append(all, "--"+name+"="+value)
+		}
+		parseFlags(t, "--type=annuity --solve="+target+" "+strings.Join(all, " "))
+		if got, err := getAnnualAction(); got != action || err != nil {
+			t.Errorf("--solve=%s with all four: %v, %v", target, got, err)
+		}
+
+		for _, missing := range needs {
+			var loan []string
+			for _, name := range needs {
+				if name != missing {
+					loan = append(loan, "--"+name+"="+values[name])
+				}
+			}
+
+			code, fields := jsonError(t, "--type=annuity --solve="+target+" "+strings.Join(loan, " "))
+			if code != "under-specified" || strings.Join(fields, ",") != missing {
+				t.Errorf("--solve=%s without %s: %s %v", target, missing, code, fields)
+			}
+		}
+	}
+
+	if code, fields := jsonError(t, "--type=annuity --solve=rate --principal=1000 --payment=100 --periods=12"); code != "out-of-range" || fields[0] != "solve" {
+		t.Errorf("an unknown target: %s %v", code, fields)
+	}
+}
+
+// verboseFigure scans the --verbose line starting with prefix, or fails.
+func verboseFigure(t *testing.T, args, format string) float64 {
+	t.Helper()
+
+	out, _, _ := runArgs(t, args+" --verbose")
+
+	prefix, _, _ := strings.Cut(format, "%")
+	k := strings.Index(out, "\n"+prefix)
+	if k < 0 {
+		t.Fatalf("%s: no %q in\n%s", args, prefix, out)
+	}
+
+	var v float64
+	if _, err := fmt.Sscanf(out[k+1:], format, &v); err != nil {
+		t.Fatalf("%s: %v in\n%s", args, err, out)
+	}
+
+	return v
+}
+
+func TestAmortizationFactor(t *testing.T) {
+	for _, args := range []string{
+		"--type=annuity --principal=1000000 --periods=60 --interest=10",
+		"--type=annuity --principal=250000 --periods=360 --interest=6.5",
+		"--type=annuity --principal=12345 --periods=12 --interest=0.5",
+	} {
+		parseFlags(t, args)
+		p, payment := principal, math.Ceil(getAmortizer().annuityPayment())
+
+		factor := verboseFigure(t, args, "Amortization factor = %g per 1000 of principal")
+		if got := factor * p / 1000; math.Abs(got-payment) > 0.00005*p/1000 {
+			t.Errorf("%s: a factor of %g makes a payment of %g, not %g", args, factor, got, payment)
+		}
+	}
+
+	if out, _, _ := runArgs(t, "--type=annuity --principal=0 --periods=60 --interest=10 --verbose"); strings.Contains(out, "factor") {
+		t.Errorf("a zero principal: %q", out)
+	}
+}
+
+// TestWarnTerm checks that a payment barely over the interest warns of the
+// term it takes on stderr, still printing the result, and a comfortable
+// one doesn't.
+func TestWarnTerm(t *testing.T) {
+	for _, c := range []struct{ args, want string }{
+		// the interest alone is 500 a month
+		{"--payment=501", "Warning: 1247 months is over 480, the payment may be close to covering only the interest\n"},
+		{"--payment=700 --warn-term=100", "Warning: 252 months is over 100, the payment may be close to covering only the interest\n"},
+		{"--payment=700", ""},
+		{"--payment=2000", ""},
+	} {
+		out, errOut, _ := runArgs(t, "--type=annuity --principal=100000 --interest=6 "+c.args)
+		if errOut != c.want || !strings.Contains(out, "\nOverpayment = ") {
+			t.Errorf("%s: %q\n%s", c.args, errOut, out)
+		}
+	}
+}
+
+// TestInterestSubsidy compares a subsidized loan with the same loan at the
+// full rate and at the rate less the subsidy.
+func TestInterestSubsidy(t *testing.T) {
+	for _, loan := range []string{
+		"--type=annuity --principal=100000 --periods=120",
+		"--type=diff --principal=100000 --periods=12",
+	} {
+		subsidized, _, _ := runArgs(t, loan+" --interest=6 --interest-subsidy=2")
+		full, _, _ := runArgs(t, loan+" --interest=6")
+		reduced, _, _ := runArgs(t, loan+" --interest=4")
+
+		var with, without, saved, fullOverpayment float64
+		tail := subsidized[strings.LastIndex(subsidized, "Overpayment = "):]
+		if _, err := fmt.Sscanf(tail, "Overpayment = %g\nWithout the 2%% subsidy the overpayment would be %g, so it saves %g\n", &with, &without, &saved); err != nil {
+			t.Fatalf("%s: %v in\n%s", loan, err, subsidized)
+		}
+		fmt.Sscanf(full[strings.LastIndex(full, "Overpayment = "):], "Overpayment = %g", &fullOverpayment)
+
+		if !strings.HasPrefix(subsidized, strings.TrimSuffix(reduced, "\n")) || without != fullOverpayment || saved != without-with || saved <= 0 {
+			t.Errorf("%s:\n%sat the full rate:\n%sat the reduced one:\n%s", loan, subsidized, full, reduced)
+		}
+	}
+
+	if out, _, _ := runArgs(t, "--type=annuity --principal=100000 --periods=120 --interest=6 --interest-subsidy=7"); out != "Incorrect parameters\n" {
+		t.Errorf("a subsidy over the rate: %q", out)
+	}
+}
+
+func TestLoanConstant(t *testing.T) {
+	for _, args := range []string{
+		"--type=annuity --principal=1000000 --periods=60 --interest=10",
+		"--type=annuity --principal=250000 --periods=360 --interest=6.5",
+		"--type=annuity --principal=5000000 --periods=300 --interest=7.25",
+	} {
+		parseFlags(t, args)
+		p, payment := principal, math.Ceil(getAmortizer().annuityPayment())
+
+		constant := verboseFigure(t, args, "Annual loan constant = %g%%")
+		if want := 12 * payment / p * 100; math.Abs(constant-want) > 0.00005 {
+			t.Errorf("%s: %g%%, 12 payments of %g make %g%%", args, constant, payment, want)
+		}
+	}
+
+	if out, _, _ := runArgs(t, "--type=annuity --principal=0 --periods=60 --interest=10 --verbose"); strings.Contains(out, "constant") {
+		t.Errorf("a zero principal: %q", out)
+	}
+}
+
+// TestRuns checks that a failing -run reports its error inline without
+// stopping the ones after it.
+func TestRuns(t *testing.T) {
+	out, _, _ := runArgv(t,
+		"--run=--type=annuity --principal=1000 --periods=12 --interest=5",
+		"--run=--type=annuity --principal=1000",
+		"--run=--type=diff --principal=1000 --periods=2 --interest=5")
+
+	want := "[1] --type=annuity --principal=1000 --periods=12 --interest=5\n" +
+		"Your annuity payment = 86!\nOverpayment = 32\n\n" +
+		"[2] --type=annuity --principal=1000\nIncorrect parameters\n\n" +
+		"[3] --type=diff --principal=1000 --periods=2 --interest=5\n" +
+		"Month 1: payment is 505\nMonth 2: payment is 503\n\nOverpayment = 8\n"
+	if out != want {
+		t.Errorf("output:\n%s", out)
+	}
+}
+
+// TestMaxTerm checks the longest term within a total interest around the
+// boundary: 108 months overpay 29708, 109 months 30037.
+func TestMaxTerm(t *testing.T) {
+	for _, c := range []struct {
+		limit string
+		want  string
+	}{
+		{"30000", "It will take 9 years to repay this loan!\nYour annuity payment = 1201!\nOverpayment = 29708\n"},
+		{"30036", "It will take 9 years to repay this loan!\nYour annuity payment = 1201!\nOverpayment = 29708\n"},
+		{"30037", "It will take 9 years and 1 month to repay this loan!\nYour annuity payment = 1193!\nOverpayment = 30037\n"},
+		{"29708", "It will take 9 years to repay this loan!\nYour annuity payment = 1201!\nOverpayment = 29708\n"},
+		{"29707", "It will take 8 years and 11 months to repay this loan!\n"},
+		{"100", "Even a single payment costs more than 100 of interest\n"},
+	} {
+		out, _, _ := runArgs(t, "--type=annuity --principal=100000 --interest=6 --max-overpayment="+c.limit)
+		if !strings.HasPrefix(out, c.want) {
+			t.Errorf("%s: %q, want %q", c.limit, out, c.want)
+		}
+	}
+}
+
+// TestErrorFields checks the flags the JSON errors attribute an out of
+// range value or a missing one to, while the text error stays the same.
+func TestErrorFields(t *testing.T) {
+	for _, c := range []struct {
+		args, code, fields string
+	}{
+		{"--type=annuity --principal=1000 --periods=12 --interest=-3", "out-of-range", "interest"},
+		{"--type=diff --principal=1000 --periods=12 --interest=-3", "out-of-range", "interest"},
+		{"--principal=1000 --periods=12 --interest=5", "out-of-range", "type"},
+		{"--type=annuity --principal=1000 --periods=12", "under-specified", "payment,interest"},
+		{"--type=annuity --principal=1000", "under-specified", "periods,payment,interest"},
+		{"--type=diff --principal=1000 --periods=12", "under-specified", "interest"},
+		{"--type=diff --interest=5", "under-specified", "principal,periods"},
+		{"--interest-only --principal=1000 --interest=5", "under-specified", "periods"},
+		{"--interest-only --principal=1000 --interest=5 --periods=0", "out-of-range", "periods"},
+		{"--type=diff --principal=1000 --periods=12 --interest=5 --solve=period", "out-of-range", "solve"},
+		{"--type=diff --principal=1000 --periods=12 --interest=5 --max-overpayment=50", "conflicting", "principal,max-overpayment"},
+		{"--type=diff --periods=12 --max-overpayment=50", "under-specified", "interest"},
+		{"--type=annuity --principal=1000 --periods=12 --payment=10", "out-of-range", "payment"},
+		{"--type=annuity --principal=1000 --payment=10 --max-overpayment=5 --interest=5", "out-of-range", "max-overpayment"},
+	} {
+		if code, fields := jsonError(t, c.args); code != c.code || strings.Join(fields, ",") != c.fields {
+			t.Errorf("%s: %s %v, want %s %s", c.args, code, fields, c.code, c.fields)
+		}
+
+		if out, _, _ := runArgs(t, c.args); out != "Incorrect parameters\n" {
+			t.Errorf("%s: %q", c.args, out)
+		}
+	}
+}
+
+// TestCostPer1000 compares a small short loan with a large long one at the
+// same rate: per 1000 and year, the long one costs more, as its balance
+// stays high for longer, while two sizes of the same term cost about the
+// same.
+func TestCostPer1000(t *testing.T) {
+	cost := func(args string) float64 {
+		return verboseFigure(t, "--type=annuity --interest=6 "+args, "Annualized cost = %g per 1000 of principal per year")
+	}
+
+	small, large := cost("--principal=10000 --periods=12"), cost("--principal=1000000 --periods=360")
+	if small != 33.20 || large != 38.62 {
+		t.Errorf("10000 over a year costs %g, 1000000 over 30 years %g", small, large)
+	}
+
+	// 1158560 of interest per 1000 of 1000000 over 30 years
+	if want := 1158560.0 / 1000 / 30; math.Abs(large-want) > 0.005 {
+		t.Errorf("the long loan costs %g, not %g", large, want)
+	}
+
+	if same := cost("--principal=500000 --periods=12"); math.Abs(same-small) > 0.5 {
+		t.Errorf("500000 over a year costs %g, 10000 %g", same, small)
+	}
+
+	if out, _, _ := runArgs(t, "--type=annuity --interest=6 --principal=0 --periods=12 --verbose"); strings.Contains(out, "Annualized") {
+		t.Errorf("a zero principal: %q", out)
+	}
+}
+
+// TestCapitalizeFees finances the fee of 500 instead of paying it upfront:
+// the payment rises, and the total cost with it by the interest on the fee.
+func TestCapitalizeFees(t *testing.T) {
+	const loan = "--type=annuity --principal=10000 --periods=60 --interest=6 --fee=500"
+
+	upfront, _, _ := runArgs(t, loan)
+	if want := "Your annuity payment = 194!\nOverpayment = 1640\nTotal cost = 2140, including the upfront fee of 500\n"; upfront != want {
+		t.Errorf("upfront: %q, want %q", upfront, want)
+	}
+
+	capitalized, _, _ := runArgs(t, loan+" --capitalize-fees")
+	if want := "Your annuity payment = 203!\nOverpayment = 1680\nTotal cost = 2180, including the capitalized fee of 500\n"; capitalized != want {
+		t.Errorf("capitalized: %q, want %q", capitalized, want)
+	}
+
+	// without a fee there is nothing to capitalize
+	plain, _, _ := runArgs(t, "--type=annuity --principal=10000 --periods=60 --interest=6")
+	if without, _, _ := runArgs(t, "--type=annuity --principal=10000 --periods=60 --interest=6 --capitalize-fees"); without != plain {
+		t.Errorf("no fee: %q, want %q", without, plain)
+	}
+}
+
+// TestCompareFrequency pays 200000 over 30 years at 6% biweekly, which
+// comes to 13 monthly payments a year and repays it 5.5 years sooner.
+func TestCompareFrequency(t *testing.T) {
+	const loan = "--type=annuity --principal=200000 --periods=360 --interest=6 --compare-frequency"
+
+	out, _, _ := runArgs(t, loan)
+	want := "Paying 1200 monthly takes 30 years with 231096.94 of interest\n" +
+		"Paying 600 biweekly takes 24 years and 6 months with 181035.74 of interest\n" +
+		"Paying biweekly saves 5 years and 6 months and 50061.20 of interest\n"
+	if out != want {
+		t.Errorf("got\n%s\nwant\n%s", out, want)
+	}
+
+	if out, _, _ := runArgs(t, loan+" --payment-frequency=biweekly"); out != "Incorrect parameters\n" {
+		t.Errorf("an already biweekly loan: %q", out)
+	}
+}
+
+// TestFractionalPeriod checks that the exact term lies within the last of
+// the whole months the default mode reports.
+func TestFractionalPeriod(t *testing.T) {
+	for _, c := range []struct {
+		loan  string
+		exact float64
+	}{
+		{"--principal=10000 --payment=300 --interest=6", 36.56},
+		{"--principal=1000 --payment=100 --interest=12", 10.59},
+		{"--principal=10000 --payment=1000 --interest=0", 10},
+	} {
+		got := verboseFigure(t, "--type=annuity --fractional-period "+c.loan, "Exactly %g months")
+
+		out, _, _ := runArgs(t, "--type=annuity --format=json "+c.loan)
+		var r Result
+		if err := json.Unmarshal([]byte(out), &r); err != nil {
+			t.Fatalf("%s: %v in %s", c.loan, err, out)
+		}
+
+		if got != c.exact || got > float64(r.Periods) || got <= float64(r.Periods-1) {
+			t.Errorf("%s: exactly %g months, of %d", c.loan, got, r.Periods)
+		}
+	}
+}
+
+// TestRoundPaymentUp rounds the payment of 194 up to 200, which repays the
+// loan 2 months sooner and saves the difference of the schedules' interest.
+func TestRoundPaymentUp(t *testing.T) {
+	scheduled := func(loan string) (int, Money) {
+		out, _, _ := runArgs(t, "--type=annuity --format=json "+loan)
+
+		var r Result
+		if err := json.Unmarshal([]byte(out), &r); err != nil {
+			t.Fatalf("%s: %v in %s", loan, err, out)
+		}
+
+		return len(r.Schedule), r.Schedule[len(r.Schedule)-1].CumulativeInterest
+	}
+
+	months, interest := scheduled("--principal=10000 --periods=60 --interest=6")
+	roundedMonths, roundedInterest := scheduled("--principal=10000 --payment=200 --interest=6")
+	if months != 60 || roundedMonths != 58 {
+		t.Fatalf("the schedules take %d and %d months", months, roundedMonths)
+	}
+
+	out, _, _ := runArgs(t, "--type=annuity --principal=10000 --periods=60 --interest=6 --round-payment-up-to=50")
+	if want := fmt.Sprintf("Paying 200 per month takes 4 years and 10 months, 2 months sooner, saving %s of interest\n", interest.Sub(roundedInterest)); !strings.HasSuffix(out, want) {
+		t.Errorf("got %q, want it to end in %q", out, want)
+	}
+
+	// a payment that's already a multiple takes as long
+	out, _, _ = runArgs(t, "--type=annuity --principal=200000 --periods=360 --interest=6 --round-payment-up-to=100")
+	if !strings.HasSuffix(out, "Paying 1200 per month takes as long, saving 0 of interest\n") {
+		t.Errorf("a payment of 1200: %q", out)
+	}
+
+	// a step below the money unit rounds to nothing
+	for _, args := range []string{"--round-payment-up-to=0.001", "--round-payment-up-to=0.5"} {
+		if code, fields := jsonError(t, "--type=annuity --principal=10000 --periods=60 --interest=6 "+args); code != "out-of-range" || fmt.Sprint(fields) != "[round-payment-up-to]" {
+			t.Errorf("%s: %s %v", args, code, fields)
+		}
+	}
+}
+
+func TestFirstInterest(t *testing.T) {
+	for _, c := range []struct {
+		loan                     string
+		principal, rate, payment float64
+	}{
+		{"--principal=10000 --periods=60 --interest=6", 10000, 6, 194},
+		{"--principal=250000 --periods=360 --interest=4.5", 250000, 4.5, 1267},
+		// the solved principal, 800018
+		{"--payment=8722 --periods=120 --interest=5.6", 800018, 5.6, 8722},
+	} {
+		out, _, _ := runArgs(t, "--type=annuity --first-interest "+c.loan)
+
+		interest := moneyOf(c.principal * c.rate / 1200)
+		want := fmt.Sprintf("Of the first payment of %s, %s is interest and %s principal\n", moneyOf(c.payment), interest, moneyOf(c.payment).Sub(interest))
+		if !strings.HasSuffix(out, want) {
+			t.Errorf("%s: %q, want it to end in %q", c.loan, out, want)
+		}
+	}
+}
+
+func TestElapsed(t *testing.T) {
+	for _, c := range []struct {
+		original, remaining int
+		want                string
+	}{
+		{360, 300, "60 of 360 payments made, 16.7% of the term elapsed\n"},
+		{360, 360, "0 of 360 payments made, 0.0% of the term elapsed\n"},
+		{60, 1, "59 of 60 payments made, 98.3% of the term elapsed\n"},
+		{300, 360, "Incorrect parameters\n"},
+	} {
+		out, _, _ := runArgs(t, fmt.Sprintf("--type=annuity --principal=100000 --interest=5 --original-periods=%d --periods=%d", c.original, c.remaining))
+		if !strings.HasSuffix(out, c.want) {
+			t.Errorf("%d of %d left: %q, want it to end in %q", c.remaining, c.original, out, c.want)
+		}
+	}
+}
+
+// TestDiffZeroPeriods is a regression test for diff payments of a zero term,
+// which divided the principal by zero into an infinite schedule.
+func TestDiffZeroPeriods(t *testing.T) {
+	const loan = "--type=diff --principal=1000 --periods=0 --interest=5"
+
+	if out, _, _ := runArgs(t, loan); out != "The number of periods must be at least 1\n" {
+		t.Errorf("got %q", out)
+	}
+
+	out, _, _ := runArgs(t, loan+" --format=json")
+	if want := `{"schema_version":1,"error":{"code":"error","message":"The number of periods must be at least 1"}}` + "\n"; out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+}
+
+// TestInterestOnlyPhases pays interest only for 5 years of 30, then
+// amortizes the whole principal over the remaining 25, at a higher payment
+// than amortizing it over all 30. The overpayment is what the 60 payments
+// of 500 and 300 of 645 pay beyond the principal.
+func TestInterestOnlyPhases(t *testing.T) {
+	out, _, _ := runArgs(t, "--interest-only-months=60 --principal=100000 --periods=360 --interest=6")
+	want := "Interest-only payment for the first 60 months = 500\n" +
+		"Amortizing payment for the remaining 300 months = 645\n" +
+		"Overpayment = 123500\n"
+	if out != want {
+		t.Errorf("got\n%s\nwant\n%s", out, want)
+	}
+
+	for loan, want := range map[string]string{
+		// the whole term, paying less
+		"--principal=100000 --periods=360 --interest=6": "Your annuity payment = 600!\n",
+		// the remaining term, paying the same
+		"--principal=100000 --periods=300 --interest=6": "Your annuity payment = 645!\n",
+	} {
+		if out, _, _ := runArgs(t, "--type=annuity "+loan); !strings.HasPrefix(out, want) {
+			t.Errorf("%s: %q, want %q", loan, out, want)
+		}
+	}
+}
+
+// TestWarnOverpaymentRatio trips a ratio of 1 with 30 years at 9%, whose
+// interest is almost twice the principal, but not with 5 years.
+func TestWarnOverpaymentRatio(t *testing.T) {
+	for _, c := range []struct {
+		args, warning string
+	}{
+		{"--periods=360 --warn-overpayment-ratio=1", "WARNING: the overpayment of 189800 is 1.90 times the principal, over the limit of 1\n"},
+		{"--periods=360 --warn-overpayment-ratio=2", ""},
+		{"--periods=60 --warn-overpayment-ratio=1", ""},
+		{"--periods=360", ""},
+	} {
+		out, errOut, code := runArgs(t, "--type=annuity --principal=100000 --interest=9 "+c.args)
+		if errOut != c.warning || code != 0 || !strings.HasPrefix(out, "Your annuity payment = ") {
+			t.Errorf("%s: exit %d, %q, warning %q", c.args, code, out, errOut)
+		}
+	}
+}
+
+// TestShortenBy takes 5 years off a 30-year loan: the saving is the
+// overpayment of 30 years less that of 25.
+func TestShortenBy(t *testing.T) {
+	const loan = "--type=annuity --principal=200000 --interest=6 "
+
+	overpayment := func(periods string) (Money, Money) {
+		out, _, _ := runArgs(t, loan+"--format=json --periods="+periods)
+
+		var r Result
+		if err := json.Unmarshal([]byte(out), &r); err != nil {
+			t.Fatalf("%v in %.200s", err, out)
+		}
+		return r.Payment, r.Overpayment
+	}
+
+	payment30, over30 := overpayment("360")
+	payment25, over25 := overpayment("300")
+
+	out, _, _ := runArgs(t, loan+"--periods=360 --shorten-by-years=5")
+	want := fmt.Sprintf("To repay it 5 years sooner pay %s, %s more, saving %s of interest\n", payment25, payment25.Sub(payment30), over30.Sub(over25))
+	if !strings.HasSuffix(out, want) || want != "To repay it 5 years sooner pay 1289, 89 more, saving 45300 of interest\n" {
+		t.Errorf("got %q, want it to end in %q", out, want)
+	}
+
+	for _, years := range []string{"30", "31"} {
+		if out, _, _ := runArgs(t, loan+"--periods=360 --shorten-by-years="+years); out != "Incorrect parameters\n" {
+			t.Errorf("%s years sooner: %q", years, out)
+		}
+	}
+}
+
+// TestAffordability affords a house on 2500 a month over 30 years at 6%,
+// paying 400 of it in taxes and insurance and putting 60000 down.
+func TestAffordability(t *testing.T) {
+	out, _, _ := runArgs(t, "--budget=2500 --periods=360 --interest=6 --monthly-tax=300 --monthly-insurance=100 --down-payment=60000")
+	want := "A budget of 2500 less 400 of taxes and insurance leaves 2100 a month for the loan\n" +
+		"It pays off a principal of 350262\n" +
+		"With the down payment: 350262 + 60000 = an affordable price of 410262\n"
+	if out != want {
+		t.Errorf("got\n%s\nwant\n%s", out, want)
+	}
+
+	// the principal is what the principal mode finds for 2100
+	if out, _, _ := runArgs(t, "--type=annuity --payment=2100 --periods=360 --interest=6"); !strings.HasPrefix(out, "Your loan principal = 350262!\n") {
+		t.Errorf("the principal mode: %q", out)
+	}
+
+	if out, _, _ := runArgs(t, "--budget=300 --periods=360 --interest=6 --monthly-tax=300"); out != "Incorrect parameters\n" {
+		t.Errorf("a budget all taxes: %q", out)
+	}
+}
+
+// parseFlags sets the flags, and the values getAction derives from them,
+// for the tests calling the calculations directly.
+func parseFlags(t *testing.T, args string) CalcType {
+	t.Helper()
+
+	fs := newFlagSet()
+	if err := fs.Parse(strings.Fields(args)); err != nil {
+		t.Fatal(err)
+	}
+
+	provided = make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { provided[f.Name] = true })
+
+	action, err := getAction()
+	if err != nil {
+		t.Fatalf("%s: %v", args, err)
+	}
+
+	return action
+}
+
+// TestQuietErrors runs a batch with two failing rows, one of them with an
+// unknown flag, which must count in the summary and still reach the audit
+// log without printing anything for the row.
+func TestQuietErrors(t *testing.T) {
+	log := filepath.Join(t.TempDir(), "audit.log")
+
+	out, errOut, code := runArgv(t, "--quiet-errors", "--audit-log="+log,
+		"--run=--type=annuity --principal=1000 --periods=12 --interest=5",
+		"--run=--type=annuity --principal=1000",
+		"--run=--no-such-flag=1")
+
+	if code != 1 || !strings.HasSuffix(out, "\n2 of 3 runs failed\n") {
+		t.Errorf("exit %d, output:\n%s", code, out)
+	}
+	if strings.Contains(out, "Incorrect parameters") || errOut != "" {
+		t.Errorf("errors printed:\n%s%s", out, errOut)
+	}
+
+	data, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[1], `"error":"Incorrect parameters"`) ||
+		!strings.Contains(lines[2], "no-such-flag") {
+		t.Errorf("audit log:\n%s", data)
+	}
+}
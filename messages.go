@@ -0,0 +1,189 @@
+package main
+
+// messages holds the output strings by language and key. Keys missing
+// from a language fall back to English.
+var messages = map[string]map[string]string{
+	"en": {
+		"incorrect-parameters":    "Incorrect parameters",
+		"unused-flags":            "Incorrect parameters: %s not used by the calculation",
+		"text-only":               "Incorrect parameters: this calculation only prints text, without -format, -query or -template",
+		"no-periods":              "The number of periods must be at least 1",
+		"unknown-format":          "Unknown format %q\n",
+		"solver-failed":           "The solver did not converge in %d iterations, last residual %g",
+		"no-max-period":           "Even a single payment costs more than %s of interest",
+		"run-header":              "[%d] %s\n",
+		"runs-failed":             "%d of %d runs failed\n",
+		"audit-failed":            "Could not write the audit log: %v\n",
+		"bad-input-json":          "Malformed input JSON: %v",
+		"unknown-input-key":       "Unknown input key %q",
+		"bad-input-value":         "Input key %q must be a string, number or boolean",
+		"query-invalid":           "Invalid query %q",
+		"query-field":             "Unknown field %q",
+		"query-index":             "Index %d is out of range for %s of %d items",
+		"year":                    "1 year",
+		"years":                   "%d years",
+		"month":                   "1 month",
+		"months":                  "%d months",
+		"and":                     " and ",
+		"period":                  "It will take %s to repay this loan!\n",
+		"final-payment":           "Final payment will be %s instead of %s\n",
+		"draw-interest":           "Interest during the %d-month draw period = %s\n",
+		"warn-term":               "Warning: %d months is over %d, the payment may be close to covering only the interest\n",
+		"warn-ratio":              "WARNING: the overpayment of %s is %.2f times the principal, over the limit of %g\n",
+		"principal":               "Your loan principal = %s!\n",
+		"payment":                 "Your annuity payment = %s!\n",
+		"residual":                "The last payment leaves a residual of %s\n",
+		"interest":                "Your annual interest rate = %.*f%%!\n",
+		"skip-month":              "Skipping 1 payment, it will take %s to repay this loan\n",
+		"skip-months":             "Skipping %d payments, it will take %s to repay this loan\n",
+		"shortened":               "To repay it %d years sooner pay %s, %s more, saving %s of interest\n",
+		"rounding-residual":       "You will have overpaid by %s due to rounding; the final payment is reduced to %s accordingly\n",
+		"rounding-residual-early": "You will have overpaid by %s due to rounding; the loan is repaid after %s instead\n",
+		"compact-month":           "Month %d: %s\n",
+		"compact-months":          "Months %d–%d: %s\n",
+		"crossover":               "Principal overtakes interest in month %d\n",
+		"crossover-diff":          "Diff payments repay the same principal every month, so it exceeds the interest from the start",
+		"no-crossover":            "Principal never overtakes interest",
+		"factor":                  "Amortization factor = %.4f per 1000 of principal\n",
+		"refinance-current":       "Your current payment = %s!\n",
+		"refinance-new":           "Your refinanced payment = %s!\n",
+		"refinance-saving":        "Monthly saving = %s\n",
+		"refinance-break-even":    "The closing costs of %s are recouped in month %d\n",
+		"refinance-never":         "The closing costs of %s are never recouped\n",
+		"refinance-no-costs":      "Without closing costs the refinanced loan breaks even immediately",
+		"total-cost-upfront":      "Total cost = %s, including the upfront fee of %s\n",
+		"total-cost-capitalized":  "Total cost = %s, including the capitalized fee of %s\n",
+		"compare-monthly":         "Paying %s monthly takes %s with %s of interest\n",
+		"compare-biweekly":        "Paying %s biweekly takes %s with %s of interest\n",
+		"compare-saving":          "Paying biweekly saves %s and %s of interest\n",
+		"recast":                  "Prepaying %s after month %d recasts the payment from %s to %s for the remaining %d months\n",
+		"parse-flags":             "Flags given:",
+		"parse-checks":            "Checks:",
+		"parse-pass":              "pass",
+		"parse-fail":              "FAIL",
+		"parse-error":             "Rejected: %s\n",
+		"parse-mode":              "Calculation: %s\n",
+		"parse-target":            "Solving for: %s\n",
+		"parse-target-none":       "Solving for: nothing (%v)\n",
+		"step-up":                 "From month %d the payment is %s\n",
+		"payoff":                  "Paying off after %d payments takes %s\n",
+		"modes-header":            "Mode\tSelected by\tNeeds\tDescription",
+		"round-payment-up":        "Paying %s per month takes %s, %s sooner, saving %s of interest\n",
+		"round-payment-same":      "Paying %s per month takes as long, saving %s of interest\n",
+		"compare-unreadable":      "Cannot read the result in %s: %v",
+		"batch-unreadable":        "Cannot read the batch in %s: %v",
+		"rates-unreadable":        "Cannot read the rates in %s: %v",
+		"rates-no-product":        "No rate for %q in %s",
+		"compare-incompatible":    "The result in %s has schema version %d, not %d",
+		"compare-changed":         "%s changed from %s to %s\n",
+		"compare-unchanged":       "Nothing changed since %s\n",
+		"compare-payment":         "Payment",
+		"compare-principal":       "Principal",
+		"compare-periods":         "Periods",
+		"compare-interest":        "Interest",
+		"compare-overpayment":     "Overpayment",
+		"stress-principal":        "At the stress rate of %g%% you qualify for a principal of %s with a payment of %s\n",
+		"stress-unstressed":       "At the contract rate of %g%% it would be %s\n",
+		"stress-payment":          "Your annuity payment = %s at the contract rate of %g%%!\n",
+		"offers-header":           "Lender\tRate\tTerm\tPayment\tOverpayment\tFees\tTotal cost\t",
+		"offers-cheapest":         " <- cheapest",
+		"offers-row":              "offers row %d: %w",
+		"offers-empty":            "no offers in %s",
+		"loans-row":               "loans row %d: %w",
+		"loans-empty":             "no loans in %s",
+		"consolidate-header":      "Loan\tPrincipal\tRate\tTerm\tPayment\t",
+		"consolidate-principal":   "Total principal = %s\n",
+		"consolidate-rate":        "Weighted average rate = %.4f%%\n",
+		"consolidate-payment":     "Blended monthly payment = %s\n",
+		"first-interest":          "Of the first payment of %s, %s is interest and %s principal\n",
+		"elapsed":                 "%d of %d payments made, %.1f%% of the term elapsed\n",
+		"fractional-period":       "Exactly %.2f months\n",
+		"down-payment":            "A payment of %s pays off a principal of %s, so the down payment on %s is %s\n",
+		"afford-budget":           "A budget of %s less %s of taxes and insurance leaves %s a month for the loan\n",
+		"afford-principal":        "It pays off a principal of %s\n",
+		"afford-price":            "With the down payment: %s + %s = an affordable price of %s\n",
+		"subsidy":                 "Without the %g%% subsidy the overpayment would be %s, so it saves %s\n",
+		"cap-within":              "Overpayment is within the %g%% cap of %s\n",
+		"cap-term":                "Overpayment exceeds the %g%% cap of %s, the longest term within it is %s with a payment of %s\n",
+		"cap-payment":             "Overpayment exceeds the %g%% cap of %s, the smallest payment within it is %s over %s\n",
+		"cap-none":                "Overpayment exceeds the %g%% cap of %s for any term\n",
+		"stub-separate":           "The first payment includes %s of interest for the longer first period\n",
+		"stub-capitalized":        "Interest of %s for the longer first period is added to the principal\n",
+		"loan-constant":           "Annual loan constant = %.4f%%\n",
+		"monthly-rate":            "Monthly rate used = %.6f%%\n",
+		"periodic-rate":           "Rate per payment used = %.6f%%\n",
+		"cost-per-1000":           "Annualized cost = %.2f per 1000 of principal per year\n",
+		"outlay":                  "Total monthly outlay = %s (payment %s + tax %s + insurance %s)\n",
+		"overpayment":             "Overpayment = %s\n",
+		"interest-only":           "Your interest-only payment = %s!\n",
+		"principal-due":           "The principal of %s is due with the last payment\n",
+		"interest-only-phase":     "Interest-only payment for the first %d months = %s\n",
+		"amortizing-phase":        "Amortizing payment for the remaining %d months = %s\n",
+		"diff-payment":            "Month %d: payment is %s\n",
+		"diff-outlay":             "Month %d: payment is %s, total outlay is %s\n",
+		"extra-monthly":           "With %s extra per month it will take %s, saving %s of interest\n",
+		"reconcile":               "Sum of monthly payments = %s, principal + overpayment = %s\n",
+		"reconcile-failed":        "Monthly payments differ from the total by %s",
+		"self-check-rows":         "Self-check failed: %d schedule rows for %d periods",
+		"self-check-payment":      "Self-check failed: month %d pays %s instead of %s",
+		"self-check-balance":      "Self-check failed: the schedule ends with a balance of %s",
+		"self-check-total":        "Self-check failed: the payments sum to %s instead of %s",
+		"self-check-passed":       "Self-check passed: %d payments sum to %s\n",
+		"self-check-passed-full":  "Self-check passed: %d payments sum to %s, and the %d periods in full to %s as the overpayment counts them\n",
+		"schedule-header":         "Month\tPayment\tInterest\tPrincipal\tBalance\t",
+		"schedule-reverse-header": "Month\tPayment\tInterest\tPrincipal\tLeft to pay\t",
+		"by-year-header":          "Year\tInterest\t",
+		"by-year-total":           "Total",
+		"compare-rounding-header": "Rounding\tPayment\tOverpayment\t",
+		"schedule-by-year-header": "Year\tPaid\tInterest\tPrincipal\tBalance\t",
+		"sweep-rate":              "Rate",
+		"fixed-interest":          "Interest charged on the original principal; on the declining balance the overpayment would be %s, %s less\n",
+		"explain-ear":             "Effective annual rate %g%% = periodic rate %.6f%%\n",
+		"explain-principal":       "Principal = %s\n",
+		"explain-interest":        "Interest = %s (sum over %d months)\n",
+		"explain-draw":            "Interest during the draw period = %s\n",
+		"explain-total":           "Total paid = %s\n",
+		"explain-unreduced":       "Overpayment counts %s more than the schedule pays, taking its final payment of %s in full\n",
+		"explain-rounding":        "Overpayment differs from the interest by %s due to rounding\n",
+	},
+	"de": {
+		"incorrect-parameters":    "Falsche Parameter",
+		"unused-flags":            "Falsche Parameter: %s von der Berechnung nicht verwendet",
+		"text-only":               "Falsche Parameter: diese Berechnung gibt nur Text aus, ohne -format, -query oder -template",
+		"no-periods":              "Die Anzahl der Perioden muss mindestens 1 sein",
+		"year":                    "1 Jahr",
+		"years":                   "%d Jahre",
+		"month":                   "1 Monat",
+		"months":                  "%d Monate",
+		"and":                     " und ",
+		"period":                  "Die Rückzahlung dauert %s!\n",
+		"final-payment":           "Die letzte Rate beträgt %s statt %s\n",
+		"principal":               "Ihr Darlehensbetrag = %s!\n",
+		"payment":                 "Ihre Annuitätenrate = %s!\n",
+		"interest":                "Ihr Jahreszins = %.*f%%!\n",
+		"overpayment":             "Mehrzahlung = %s\n",
+		"interest-only":           "Ihre Zinsrate = %s!\n",
+		"principal-due":           "Der Darlehensbetrag von %s ist mit der letzten Rate fällig\n",
+		"diff-payment":            "Monat %d: Rate ist %s\n",
+		"schedule-header":         "Monat\tRate\tZinsen\tTilgung\tRestschuld\t",
+		"schedule-reverse-header": "Monat\tRate\tZinsen\tTilgung\tNoch zu zahlen\t",
+		"by-year-header":          "Jahr\tZinsen\t",
+		"by-year-total":           "Summe",
+		"compare-rounding-header": "Rundung\tRate\tMehrbetrag\t",
+		"schedule-by-year-header": "Jahr\tGezahlt\tZinsen\tTilgung\tRestschuld\t",
+		"offers-header":           "Kreditgeber\tZins\tLaufzeit\tRate\tMehrzahlung\tGebühren\tGesamtkosten\t",
+		"offers-cheapest":         " <- günstigstes",
+		"offers-row":              "Angebote Zeile %d: %w",
+		"offers-empty":            "keine Angebote in %s",
+		"loans-row":               "Darlehen Zeile %d: %w",
+		"loans-empty":             "keine Darlehen in %s",
+	},
+}
+
+func msg(key string) string {
+	if m, ok := messages[lang][key]; ok {
+		return m
+	}
+
+	return messages["en"][key]
+}
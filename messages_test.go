@@ -0,0 +1,51 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestGermanOutput(t *testing.T) {
+	for _, c := range []struct{ args, want string }{
+		{"--type=annuity --principal=1000000 --periods=60 --interest=10", "Ihre Annuitätenrate = 21248!\nMehrzahlung = 274880\n"},
+		{"--type=annuity --principal=1000000 --periods=60", "Falsche Parameter\n"},
+		{"--type=diff --principal=1000 --periods=0 --interest=10", "Die Anzahl der Perioden muss mindestens 1 sein\n"},
+	} {
+		out, _, _ := runArgs(t, "--lang=de "+c.args)
+		if out != c.want {
+			t.Errorf("%s: %q, want %q", c.args, out, c.want)
+		}
+	}
+
+	path := writeFile(t, "offers.csv", "A,5,12,0\nB,x,12,0\n")
+	if out, _, _ := runArgs(t, "--lang=de --principal=1000 --offers="+path); out != "Angebote Zeile 2: Falsche Parameter\n" {
+		t.Errorf("offers error %q", out)
+	}
+
+	path = writeFile(t, "offers.csv", "A,5,12,0\n")
+	if out, _, _ := runArgs(t, "--lang=de --principal=1000 --offers="+path); !strings.Contains(out, "Kreditgeber") ||
+		!strings.Contains(out, "<- günstigstes") {
+		t.Errorf("offers table:\n%s", out)
+	}
+}
+
+var verb = regexp.MustCompile(`%[-+# 0]*[0-9.*]*[a-zA-Z%]`)
+
+// TestMessageCatalog checks that every translation has an English message
+// taking the same values.
+func TestMessageCatalog(t *testing.T) {
+	for lang, catalog := range messages {
+		for key, m := range catalog {
+			en, ok := messages["en"][key]
+			if !ok {
+				t.Errorf("%s message %q has no English one", lang, key)
+				continue
+			}
+
+			if got, want := verb.FindAllString(m, -1), verb.FindAllString(en, -1); strings.Join(got, " ") != strings.Join(want, " ") {
+				t.Errorf("%s message %q takes %v, the English one %v", lang, key, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+)
+
+// mode describes a calculation for -list-modes and -explain-parse.
+type mode struct {
+	action      CalcType
+	name        string
+	selectedBy  string
+	needs       []string
+	description string
+}
+
+// modes registers every calculation; a new CalcType belongs here too.
+var modes = []mode{
+	{CalcAnnual, "annuity", "--type=annuity", []string{"three of principal, payment, periods, interest"},
+		"Equal monthly payments, solving for the omitted value"},
+	{CalcPayment, "payment", "--type=annuity without --payment", []string{"principal", "periods", "interest"},
+		"The annuity payment"},
+	{CalcPrincipal, "principal", "--type=annuity without --principal", []string{"payment", "periods", "interest"},
+		"The principal an annuity payment pays off"},
+	{CalcPeriod, "period", "--type=annuity without --periods", []string{"principal", "payment", "interest"},
+		"The time an annuity payment takes to pay off the principal"},
+	{CalcInterest, "interest", "--type=annuity without --interest", []string{"principal", "payment", "periods"},
+		"The annual interest rate of an annuity"},
+	{CalcMaxPrincipal, "max-principal", "--type=annuity --max-overpayment", []string{"periods", "interest", "max-overpayment"},
+		"The largest principal within the overpayment"},
+	{CalcMaxPeriod, "max-period", "--type=annuity --max-overpayment", []string{"principal", "interest", "max-overpayment"},
+		"The longest term within the overpayment"},
+	{CalcDiff, "diff", "--type=diff", []string{"principal", "periods", "interest"},
+		"Differentiated payments of a fixed principal part plus interest"},
+	{CalcOffers, "offers", "--offers", []string{"principal", "offers"},
+		"Compare lender offers by total cost"},
+	{CalcConsolidation, "consolidate", "--consolidate", []string{"consolidate"},
+		"The principal-weighted rate and blended payment of several loans"},
+	{CalcInterestOnly, "interest-only", "--interest-only or --interest-only-months", []string{"principal", "periods", "interest"},
+		"Pay only the interest, and the principal at the end or over the rest of the term"},
+	{CalcRefinance, "refinance", "--new-interest", []string{"principal", "periods", "interest", "new-interest"},
+		"The month refinancing recoups its closing costs"},
+	{CalcDownPayment, "down-payment", "--target-payment", []string{"price", "periods", "interest", "target-payment"},
+		"The down payment that brings the payment down to the target"},
+	{CalcRateSweep, "rate-sweep", "--rate-sweep", []string{"principal", "rate-sweep", "terms"},
+		"A table of payments by rate and term"},
+	{CalcFrequencyComparison, "compare-frequency", "--compare-frequency", []string{"principal", "periods", "interest"},
+		"Monthly against biweekly half payments"},
+	{CalcStressTest, "stress-test", "--stress-rate", []string{"periods", "interest", "stress-rate", "max-payment"},
+		"The principal qualifying at the stress rate, and its payment at the contract rate"},
+	{CalcRoundingComparison, "compare-rounding", "--compare-rounding", []string{"principal", "periods", "interest"},
+		"The payment and overpayment under each rounding policy"},
+	{CalcAffordability, "affordability", "--budget", []string{"budget", "periods", "interest"},
+		"The highest price a monthly budget affords, with taxes, insurance and a down payment"},
+}
+
+func modeName(action CalcType) string {
+	for _, m := range modes {
+		if m.action == action {
+			return m.name
+		}
+	}
+
+	return ""
+}
+
+func displayModes() {
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, msg("modes-header"))
+
+	for _, m := range modes {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", m.name, m.selectedBy, strings.Join(m.needs, ", "), m.description)
+	}
+
+	w.Flush()
+}
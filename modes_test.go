@@ -0,0 +1,37 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestListModes(t *testing.T) {
+	saved := modes
+	defer func() { modes = saved }()
+
+	modes = append(modes[:len(modes):len(modes)], mode{CalcInvalid, "balloon", "--balloon", []string{"principal", "balloon"}, "A final lump sum"})
+
+	out, _, _ := runArgs(t, "--list-modes")
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	if len(lines) != len(modes)+1 {
+		t.Fatalf("%d lines for %d modes:\n%s", len(lines), len(modes), out)
+	}
+
+	if got := strings.Fields(lines[len(lines)-1]); strings.Join(got, " ") != "balloon --balloon principal, balloon A final lump sum" {
+		t.Errorf("the new mode is listed as %q", lines[len(lines)-1])
+	}
+}
+
+// TestModesRegistered checks that every calculation is listed once.
+func TestModesRegistered(t *testing.T) {
+	seen := map[CalcType]int{}
+	for _, m := range modes {
+		seen[m.action]++
+	}
+
+	for action := CalcAnnual; action <= CalcAffordability; action++ {
+		if seen[action] != 1 || modeName(action) == "" {
+			t.Errorf("calculation %d is registered %d times", action, seen[action])
+		}
+	}
+}
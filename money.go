@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Money is an amount held in minor units (cents), so that sums and
+// differences of displayed figures are exact.
+type Money int64
+
+// maxCents is the largest number of cents an amount may come to: beyond
+// it a float64 no longer holds every cent, and a Money soon overflows.
+const maxCents = 1 << 53
+
+// fitsMoney reports whether an amount as given, in cents with --cents,
+// can be held to the cent.
+func fitsMoney(v float64) bool {
+	if !centsMode {
+		v *= 100
+	}
+
+	return v <= maxCents
+}
+
+func moneyOf(v float64) Money {
+	return Money(math.Round(v * 100))
+}
+
+func (m Money) Add(o Money) Money {
+	return m + o
+}
+
+func (m Money) Sub(o Money) Money {
+	return m - o
+}
+
+// Mul scales the amount by a rate, rounding half away from zero to the cent.
+func (m Money) Mul(rate float64) Money {
+	return Money(math.Round(float64(m) * rate))
+}
+
+// Ceil rounds the amount up to whole units, or leaves it as is with --cents.
+func (m Money) Ceil() Money {
+	u := moneyUnit()
+	return Money(math.Ceil(float64(m)/float64(u))) * u
+}
+
+// Floor rounds the amount down to whole units, or leaves it as is with
+// --cents.
+func (m Money) Floor() Money {
+	u := moneyUnit()
+	return Money(math.Floor(float64(m)/float64(u))) * u
+}
+
+func (m Money) Float64() float64 {
+	return float64(m) / 100
+}
+
+// String prints whole amounts without a fractional part, as the rest of
+// the output always did, and others with two decimals.
+func (m Money) String() string {
+	if centsMode {
+		return fmt.Sprint(int64(m))
+	}
+
+	sign, v := "", int64(m)
+	if v < 0 {
+		sign, v = "-", -v
+	}
+
+	if v%100 == 0 {
+		return fmt.Sprintf("%s%d", sign, v/100)
+	}
+
+	return fmt.Sprintf("%s%d.%02d", sign, v/100, v%100)
+}
+
+// MarshalJSON writes the amount as a plain JSON number.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON reads back an amount written by MarshalJSON.
+func (m *Money) UnmarshalJSON(b []byte) error {
+	v, err := strconv.ParseFloat(string(b), 64)
+	if err != nil {
+		return err
+	}
+
+	if centsMode {
+		*m = Money(math.Round(v))
+	} else {
+		*m = moneyOf(v)
+	}
+
+	return nil
+}
+
+// moneyUnit is the smallest amount figures are rounded to.
+func moneyUnit() Money {
+	if centsMode {
+		return 1
+	}
+
+	return 100
+}
+
+// ceilAmount and floorAmount round a computed amount to the money unit.
+// With --cents the amount is first cleared of float noise, so that
+// e.g. 12.34*100 doesn't round up to 1235 cents.
+func ceilAmount(v float64) float64 {
+	if !centsMode {
+		return math.Ceil(v)
+	}
+
+	return math.Ceil(math.Round(v*100*1e6)/1e6) / 100
+}
+
+func floorAmount(v float64) float64 {
+	if !centsMode {
+		return math.Floor(v)
+	}
+
+	return math.Floor(math.Round(v*100*1e6)/1e6) / 100
+}
+
+// fromCents converts the amounts given in cents to the units used by the
+// calculations, rejecting fractional cents.
+func fromCents() error {
+	amounts := []struct {
+		name string
+		v    *float64
+	}{
+		{"payment", &payment}, {"principal", &principal}, {"max-overpayment", &maxOverpayment},
+		{"extra-monthly", &extraMonthly}, {"monthly-tax", &monthlyTax}, {"monthly-insurance", &monthlyInsurance},
+		{"closing-costs", &closingCosts}, {"price", &price}, {"target-payment", &targetPayment}, {"fee", &fee},
+		{"round-payment-up-to", &roundPaymentUpTo}, {"max-payment", &maxPayment}, {"residual", &residual},
+		{"budget", &budget}, {"down-payment", &downPayment},
+	}
+
+	for _, a := range amounts {
+		if *a.v >= 0 && *a.v != math.Trunc(*a.v) {
+			return outOfRange(a.name)
+		}
+		if *a.v > 0 {
+			*a.v /= 100
+		}
+	}
+
+	for name, mv := range map[string]monthValues{"disbursements": disbursements, "recast": recasts} {
+		for k := range mv {
+			if mv[k].value != math.Trunc(mv[k].value) {
+				return outOfRange(name)
+			}
+			mv[k].value /= 100
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestMoneyMul(t *testing.T) {
+	// the exactly representable half cents round away from zero
+	for _, c := range []struct {
+		m    Money
+		rate float64
+		want Money
+	}{
+		{1, 0.5, 1},
+		{-1, 0.5, -1},
+		{7, 0.5, 4},
+		{-7, 0.5, -4},
+		{10, 0.25, 3},
+		{-10, 0.25, -3},
+		{10, -0.25, -3},
+		{6, 0.25, 2},
+		{12345, 1, 12345},
+		{12345, 0, 0},
+	} {
+		if got := c.m.Mul(c.rate); got != c.want {
+			t.Errorf("%d.Mul(%g) = %d, want %d", c.m, c.rate, got, c.want)
+		}
+	}
+}
+
+func TestMoneyOf(t *testing.T) {
+	for _, c := range []struct {
+		v    float64
+		want Money
+	}{
+		{0.125, 13},
+		{-0.125, -13},
+		{0.375, 38},
+		{12.34, 1234},
+		{0.1 + 0.2, 30},
+	} {
+		if got := moneyOf(c.v); got != c.want {
+			t.Errorf("moneyOf(%g) = %d, want %d", c.v, got, c.want)
+		}
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	for _, c := range []struct {
+		m           Money
+		want, cents string
+	}{
+		{0, "0", "0"},
+		{5, "0.05", "5"},
+		{-5, "-0.05", "-5"},
+		{50, "0.50", "50"},
+		{-150, "-1.50", "-150"},
+		{100, "1", "100"},
+		{-100, "-1", "-100"},
+		{12345, "123.45", "12345"},
+	} {
+		if got := c.m.String(); got != c.want {
+			t.Errorf("%d prints %q, want %q", int64(c.m), got, c.want)
+		}
+
+		centsMode = true
+		if got := c.m.String(); got != c.cents {
+			t.Errorf("%d prints %q with --cents, want %q", int64(c.m), got, c.cents)
+		}
+		centsMode = false
+	}
+}
+
+func TestMoneyRounding(t *testing.T) {
+	for _, c := range []struct {
+		m                     Money
+		ceil, floor           Money
+		centsCeil, centsFloor Money
+	}{
+		{101, 200, 100, 101, 101},
+		{199, 200, 100, 199, 199},
+		{200, 200, 200, 200, 200},
+		{-101, -100, -200, -101, -101},
+		{0, 0, 0, 0, 0},
+	} {
+		if got := c.m.Ceil(); got != c.ceil {
+			t.Errorf("%d.Ceil() = %d, want %d", c.m, got, c.ceil)
+		}
+		if got := c.m.Floor(); got != c.floor {
+			t.Errorf("%d.Floor() = %d, want %d", c.m, got, c.floor)
+		}
+
+		centsMode = true
+		if got := c.m.Ceil(); got != c.centsCeil {
+			t.Errorf("%d.Ceil() with --cents = %d, want %d", c.m, got, c.centsCeil)
+		}
+		if got := c.m.Floor(); got != c.centsFloor {
+			t.Errorf("%d.Floor() with --cents = %d, want %d", c.m, got, c.centsFloor)
+		}
+		centsMode = false
+	}
+}
+
+func TestMoneySums(t *testing.T) {
+	// a sum of displayed figures is exact, unlike the float64 one
+	var sum Money
+	for k := 0; k < 10; k++ {
+		sum = sum.Add(moneyOf(0.1))
+	}
+
+	if sum != 100 || sum.Sub(moneyOf(1)) != 0 {
+		t.Errorf("ten times 0.10 = %s", sum)
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	for _, m := range []Money{0, 5, -150, 12345} {
+		b, err := json.Marshal(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var back Money
+		if err := json.Unmarshal(b, &back); err != nil || back != m {
+			t.Errorf("%d reads back from %s as %d, %v", m, b, back, err)
+		}
+	}
+}
+
+// TestCentsReconcile checks that a loan in whole cents reconciles to the
+// cent: its schedule pays the principal plus the overpayment exactly.
+func TestCentsReconcile(t *testing.T) {
+	for _, args := range []string{
+		"--type=annuity --principal=100000000 --periods=60 --interest=10 --reduce-final-payment",
+		"--type=annuity --principal=12345678 --periods=39 --interest=7.7 --reduce-final-payment",
+		"--type=diff --principal=100000 --periods=3 --interest=10",
+		"--type=diff --principal=99999 --periods=7 --interest=12",
+	} {
+		out, _, _ := runArgs(t, "--cents --format=json "+args)
+
+		var r Result
+		centsMode = true
+		err := json.Unmarshal([]byte(out), &r)
+		centsMode = false
+		if err != nil {
+			t.Fatalf("%s: %v in %s", args, err, out)
+		}
+
+		paid, _ := scheduleTotals(r.Schedule)
+		if paid != r.Principal.Add(r.Overpayment) {
+			t.Errorf("%s: the schedule pays %d, the principal and overpayment are %d", args, paid, r.Principal.Add(r.Overpayment))
+		}
+
+		if out, _, _ := runArgs(t, "--cents --self-check "+args); strings.HasPrefix(args, "--type=annuity") && !strings.Contains(out, "Self-check passed") {
+			t.Errorf("%s:\n%s", args, out)
+		}
+	}
+
+	if out, _, _ := runArgs(t, "--type=annuity --cents --principal=100000050.5 --periods=60 --interest=10"); out != "Incorrect parameters\n" {
+		t.Errorf("a fraction of a cent: %q", out)
+	}
+
+	for _, c := range []struct{ args, field string }{
+		{"--principal=100000050.5 --periods=60", "principal"},
+		{"--principal=100000000 --periods=60 --fee=0.5", "fee"},
+		{"--principal=100000000 --periods=60 --recast=3:10.5", "recast"},
+	} {
+		if code, fields := jsonError(t, "--type=annuity --cents --interest=10 "+c.args); code != "out-of-range" || fmt.Sprint(fields) != "["+c.field+"]" {
+			t.Errorf("%s: %s %v", c.args, code, fields)
+		}
+	}
+}
+
+// TestCentsAmounts checks that every feature's amounts are taken in cents
+// with --cents, whether plain flags or "month:amount" lists.
+func TestCentsAmounts(t *testing.T) {
+	for _, c := range []struct{ args, want string }{
+		{"--principal=100000000 --periods=120 --residual=50000000", "The last payment leaves a residual of 50000000\n"},
+		{"--budget=200000 --periods=360 --down-payment=5000000",
+			"It pays off a principal of 33358322\nWith the down payment: 33358322 + 5000000 = an affordable price of 38358322\n"},
+		{"--principal=100000000 --periods=120 --recast=12:1000000", "Prepaying 1000000 after month 12 recasts the payment from 1110206 to 1098200"},
+		{"--principal=10000000 --periods=120 --disbursements=1:5000000,6:5000000", "Interest during the 6-month draw period = 175000\n"},
+	} {
+		if out, _, _ := runArgs(t, "--type=annuity --interest=6 --cents "+c.args); !strings.Contains(out, c.want) {
+			t.Errorf("%s:\n%s", c.args, out)
+		}
+	}
+}
+
+// TestAmountRange accepts amounts up to 2^53 cents, the last a float64
+// holds to the cent, and rejects those beyond before any conversion.
+func TestAmountRange(t *testing.T) {
+	for _, c := range []struct {
+		args  string
+		field string
+	}{
+		{"--type=annuity --principal=9e13 --periods=12 --interest=5", ""},
+		{"--type=annuity --principal=1e14 --periods=12 --interest=5", "principal"},
+		{"--type=annuity --principal=1e20 --periods=12 --interest=5", "principal"},
+		{"--type=annuity --principal=9e15 --periods=12 --interest=5 --cents", ""},
+		{"--type=annuity --principal=1e16 --periods=12 --interest=5 --cents", "principal"},
+		{"--type=annuity --payment=9e13 --periods=12 --interest=5", ""},
+		{"--type=annuity --payment=1e14 --periods=12 --interest=5", "payment"},
+		{"--budget=1e14 --periods=360 --interest=5", "budget"},
+	} {
+		code, fields := jsonError(t, c.args)
+		if c.field == "" {
+			if code != "" {
+				t.Errorf("%s: rejected as %s %v", c.args, code, fields)
+			}
+			continue
+		}
+		if code != "out-of-range" || strings.Join(fields, ",") != c.field {
+			t.Errorf("%s: %q %v, want out-of-range %s", c.args, code, fields, c.field)
+		}
+	}
+
+	if !fitsMoney(maxCents/100) || fitsMoney(maxCents/100*1.0001) {
+		t.Errorf("the boundary isn't at %d cents", maxCents)
+	}
+}
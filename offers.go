@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+type offer struct {
+	lender      string
+	interest    float64
+	periods     int
+	fees        Money
+	payment     float64
+	overpayment Money
+}
+
+func (o offer) totalCost() Money {
+	return o.overpayment.Add(o.fees)
+}
+
+func doOffersComparison() error {
+	if !isProvided("principal") {
+		return underSpecified("principal")
+	}
+
+	if principal <= 0 {
+		return outOfRange("principal")
+	}
+
+	offers, err := readOffers(offersFile)
+	if err != nil {
+		return err
+	}
+
+	base := principal
+
+	for k := range offers {
+		interest, periods = offers[k].interest, offers[k].periods
+		if capitalizeFees {
+			principal = base + offers[k].fees.Float64()
+		}
+		payment = getAmortizer().annuityPayment()
+
+		offers[k].payment = payment
+		offers[k].overpayment = calculateOverpayment()
+	}
+
+	// offers of the same total cost go by lender name, then file order
+	sort.SliceStable(offers, func(a, b int) bool {
+		if offers[a].totalCost() != offers[b].totalCost() {
+			return offers[a].totalCost() < offers[b].totalCost()
+		}
+		return offers[a].lender < offers[b].lender
+	})
+
+	displayOffers(offers)
+
+	return nil
+}
+
+// readOffers loads "lender,rate,term,fees" rows, skipping an optional
+// header row.
+func readOffers(path string) ([]offer, error) {
+	var offers []offer
+
+	err := readRecords(path, "offers", 4, []int{1, 2, 3}, func(record []string) error {
+		o, err := parseOffer(record)
+		offers = append(offers, o)
+		return err
+	})
+
+	return offers, err
+}
+
+func parseOffer(record []string) (offer, error) {
+	rate, err := parseFinite(record[1])
+	if err != nil || rate < 0 {
+		return offer{}, outOfRange("offers")
+	}
+
+	term, err := strconv.Atoi(record[2])
+	if err != nil || term <= 0 {
+		return offer{}, outOfRange("offers")
+	}
+
+	fees, err := parseFinite(record[3])
+	if err != nil || fees < 0 {
+		return offer{}, outOfRange("offers")
+	}
+
+	if centsMode {
+		fees /= 100
+	}
+
+	return offer{lender: strings.TrimSpace(record[0]), interest: rate, periods: term, fees: moneyOf(fees)}, nil
+}
+
+func displayOffers(offers []offer) {
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+
+	fmt.Fprintln(w, msg("offers-header"))
+
+	for k, o := range offers {
+		fmt.Fprintf(w, "%s\t%g%%\t%d\t%s\t%s\t%s\t%s\t", o.lender, o.interest, o.periods,
+			moneyOf(o.payment), o.overpayment, o.fees, o.totalCost())
+
+		if k == 0 {
+			fmt.Fprint(w, msg("offers-cheapest"))
+		}
+
+		fmt.Fprintln(w)
+	}
+
+	w.Flush()
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// offerLenders returns the lenders of the offers table in the order
+// printed, and the one marked cheapest.
+func offerLenders(out string) (lenders []string, cheapest string) {
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+
+	for _, line := range lines[1:] {
+		lender := strings.Fields(line)[0]
+		if strings.HasSuffix(line, msg("offers-cheapest")) {
+			cheapest = lender
+		}
+		lenders = append(lenders, lender)
+	}
+
+	return lenders, cheapest
+}
+
+// TestOffersOrder sorts by the total cost, so that a lower rate with fees
+// may beat a higher one without, and a long term never looks cheap for
+// its low payment.
+func TestOffersOrder(t *testing.T) {
+	path := writeFile(t, "offers.csv", "lender,rate,term,fees\nDear,9,60,0\nCheap,4,60,500\nFeeless,5,60,0\nLong,3,360,0\n")
+
+	out, _, _ := runArgs(t, "--principal=100000 --offers="+path)
+
+	lenders, cheapest := offerLenders(out)
+	if strings.Join(lenders, ",") != "Cheap,Feeless,Dear,Long" || cheapest != "Cheap" {
+		t.Errorf("ordered %v, cheapest %q:\n%s", lenders, cheapest, out)
+	}
+	if !strings.Contains(out, "Cheap    4%    60     1842        10520   500       11020") {
+		t.Errorf("the cheapest offer's figures:\n%s", out)
+	}
+
+	// a 0% promotion costs only its fees and the payment rounded up
+	out, _, _ = runArgs(t, "--principal=100000 --offers="+writeFile(t, "promo.csv", "Promo,0,60,1500\nBank,3,60,0\n"))
+	if !strings.Contains(out, "Promo    0%    60     1667           20  1500        1520 <- cheapest\n") {
+		t.Errorf("the 0%% offer:\n%s", out)
+	}
+
+	// capitalized, the fee costs interest too
+	out, _, _ = runArgs(t, "--principal=100000 --capitalize-fees --offers="+path)
+	if !strings.Contains(out, "Cheap    4%    60     1851        10560   500       11060") {
+		t.Errorf("the capitalized fee:\n%s", out)
+	}
+}
+
+// TestOffersTies checks that offers of the same total cost go by lender
+// name, then by their order in the file, the same on every run.
+func TestOffersTies(t *testing.T) {
+	for _, c := range []struct{ content, want string }{
+		{"B,5,60,0\nA,5,60,0\nC,5,60,0\n", "A,B,C"},
+		{"C,5,60,0\nB,5,60,0\nA,5,60,0\n", "A,B,C"},
+		// a lower rate with a fee costing as much as the higher one
+		{"Zed,5,60,0\nAlpha,4,60,2760\nMid,3,60,0\n", "Mid,Alpha,Zed"},
+		{"Same,5,60,0\nSame,4,60,2760\nA,1,12,0\n", "A,Same,Same"},
+	} {
+		for run := 0; run < 5; run++ {
+			out, _, _ := runArgs(t, "--principal=100000 --offers="+writeFile(t, "offers.csv", c.content))
+
+			lenders, cheapest := offerLenders(out)
+			if strings.Join(lenders, ",") != c.want || cheapest != lenders[0] {
+				t.Fatalf("%q: ordered %v, cheapest %q:\n%s", c.content, lenders, cheapest, out)
+			}
+		}
+	}
+
+	// the same lender twice at the same cost keeps the file order
+	for _, c := range []struct{ content, first string }{
+		{"Same,5,60,0\nSame,4,60,2760\n", "5%"},
+		{"Same,4,60,2760\nSame,5,60,0\n", "4%"},
+	} {
+		out, _, _ := runArgs(t, "--principal=100000 --offers="+writeFile(t, "offers.csv", c.content))
+		if rows := strings.Split(out, "\n"); strings.Fields(rows[1])[1] != c.first {
+			t.Errorf("%q:\n%s", c.content, out)
+		}
+	}
+}
+
+// TestOffersErrors names the flag at fault, the -offers file for a bad row.
+func TestOffersErrors(t *testing.T) {
+	good := writeFile(t, "good.csv", "Bank,5,60,100\n")
+	bad := writeFile(t, "bad.csv", "Bank,5,60,100\nUnion,5,0,100\n")
+
+	for _, c := range []struct{ args, want string }{
+		{"--offers=" + good, "under-specified [principal]"},
+		{"--offers=" + good + " --principal=0", "out-of-range [principal]"},
+		{"--offers=" + bad + " --principal=10000", "out-of-range [offers]"},
+	} {
+		parseFlags(t, c.args)
+
+		var pe paramError
+		if err := doOffersComparison(); !errors.As(err, &pe) || fmt.Sprint(pe.code, " ", pe.fields) != c.want {
+			t.Errorf("%s: %v, want %s", c.args, err, c.want)
+		}
+	}
+}
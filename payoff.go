@@ -0,0 +1,19 @@
+package main
+
+import "fmt"
+
+// displayPayoff quotes the balance left after -payoff-at payments, which
+// is what paying the loan off then takes. It's read off the schedule, whose
+// interest is rounded to the cent every month, so that the quote matches
+// the balance column.
+func displayPayoff(schedule []ScheduleRow) {
+	balance := moneyOf(principal)
+	if payoffAt > 0 {
+		balance = schedule[min(payoffAt, len(schedule))-1].Balance
+	}
+	if payoffAt == periods {
+		balance = 0
+	}
+
+	fmt.Fprintf(stdout, msg("payoff"), payoffAt, balance)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+// TestPayoffAt quotes the payoff after every month of the term against the
+// balance the schedule leaves then.
+func TestPayoffAt(t *testing.T) {
+	for _, loan := range []string{
+		"--principal=1000 --periods=12 --interest=5",
+		"--principal=250000 --periods=360 --interest=4.5",
+	} {
+		out, _, _ := runArgs(t, "--type=annuity --format=json "+loan)
+
+		var r Result
+		if err := json.Unmarshal([]byte(out), &r); err != nil {
+			t.Fatalf("%s: %v in %s", loan, err, out)
+		}
+
+		for k := 0; k <= r.Periods; k++ {
+			want := r.Principal
+			if k > 0 {
+				want = r.Schedule[k-1].Balance
+			}
+
+			args := fmt.Sprintf("--type=annuity %s --payoff-at=%d", loan, k)
+			if out, _, _ := runArgs(t, args); out[len(out)-len(want.String())-1:] != want.String()+"\n" {
+				t.Errorf("%s: %q, want %s", args, out, want)
+			}
+		}
+
+		for _, k := range []int{-1, r.Periods + 1} {
+			if out, _, _ := runArgs(t, fmt.Sprintf("--type=annuity %s --payoff-at=%d", loan, k)); out != "Incorrect parameters\n" {
+				t.Errorf("%s paid off after %d: %q", loan, k, out)
+			}
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var querySegment = regexp.MustCompile(`^([a-z_]+)(?:\[(\d+)\])?$`)
+
+// formatQuery prints the single value at -query, a path such as
+// "overpayment" or "month[59].balance" over the JSON form of the result.
+func formatQuery(w io.Writer, r Result) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	var node any
+
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	if err := d.Decode(&node); err != nil {
+		return err
+	}
+
+	for _, segment := range strings.Split(query, ".") {
+		m := querySegment.FindStringSubmatch(segment)
+		if m == nil {
+			return fmt.Errorf(msg("query-invalid"), segment)
+		}
+
+		name := m[1]
+		if name == "month" {
+			name = "schedule"
+		}
+
+		fields, ok := node.(map[string]any)
+		if !ok {
+			return fmt.Errorf(msg("query-field"), name)
+		}
+		if node, ok = fields[name]; !ok {
+			return fmt.Errorf(msg("query-field"), name)
+		}
+
+		if m[2] == "" {
+			continue
+		}
+
+		k, _ := strconv.Atoi(m[2])
+
+		items, _ := node.([]any)
+		if k >= len(items) {
+			return fmt.Errorf(msg("query-index"), k, name, len(items))
+		}
+		node = items[k]
+	}
+
+	if _, ok := node.(json.Number); !ok {
+		return fmt.Errorf(msg("query-invalid"), query)
+	}
+
+	_, err = fmt.Fprintln(w, node)
+
+	return err
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"math"
+	"strconv"
+)
+
+var frequencies = map[string]int{
+	"annual":     1,
+	"semiannual": 2,
+	"quarterly":  4,
+	"monthly":    12,
+	"biweekly":   26,
+	"weekly":     52,
+	"daily":      365,
+}
+
+// compoundingPerYear and paymentsPerYear are resolved from the
+// -compounding and -payment-frequency flags by getAction.
+var compoundingPerYear, paymentsPerYear = 12, 12
+
+// frequencyPerYear takes a frequency by name or as a count per year.
+func frequencyPerYear(s string) (int, bool) {
+	if n, ok := frequencies[s]; ok {
+		return n, true
+	}
+
+	n, err := strconv.Atoi(s)
+	return n, err == nil && n > 0
+}
+
+// EffectivePeriodicRate converts a nominal annual rate in percent, compounded
+// compoundingPerYear times a year, to the effective rate per payment period.
+func EffectivePeriodicRate(nominalAnnual float64, compoundingPerYear, paymentsPerYear int) float64 {
+	r := nominalAnnual / 100 / float64(compoundingPerYear)
+	if compoundingPerYear == paymentsPerYear {
+		return r
+	}
+
+	return math.Pow(1+r, float64(compoundingPerYear)/float64(paymentsPerYear)) - 1
+}
+
+// nominalAnnualRate is the inverse of EffectivePeriodicRate.
+func nominalAnnualRate(i float64, compoundingPerYear, paymentsPerYear int) float64 {
+	c := float64(compoundingPerYear)
+	if compoundingPerYear == paymentsPerYear {
+		return i * c * 100
+	}
+
+	return (math.Pow(1+i, float64(paymentsPerYear)/c) - 1) * c * 100
+}
+
+// paymentMonths is the number of whole months the payments span.
+func paymentMonths(n int) int {
+	if paymentsPerYear == 12 {
+		return n
+	}
+
+	return int(math.Ceil(float64(n) * 12 / float64(paymentsPerYear)))
+}
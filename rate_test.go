@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+// TestRateRounded solves a loan whose exact rate is 10%, which the solver
+// only approaches, so that it must still print as a clean 10%.
+func TestRateRounded(t *testing.T) {
+	for _, c := range []struct{ args, want string }{
+		{"", "10.00%"},
+		{" --rate-precision=0", "10%"},
+		{" --rate-precision=4", "10.0000%"},
+		{" --exact", "10.00%"},
+	} {
+		out, _, _ := runArgs(t, "--type=annuity --principal=1000000 --periods=60 --payment=21247.04"+c.args)
+		if want := "Your annual interest rate = " + c.want + "!\n"; !strings.HasPrefix(out, want) {
+			t.Errorf("%s: %q, want %q", c.args, out, want)
+		}
+	}
+}
+
+// TestCanadianMortgage checks semi-annual compounding against the payments
+// Canadian lenders quote, and that solving for the rate undoes it.
+func TestCanadianMortgage(t *testing.T) {
+	for _, c := range []struct{ loan, exact string }{
+		{"--principal=100000 --interest=5 --periods=300", "581.60"},
+		{"--principal=300000 --interest=4 --periods=300", "1578.06"},
+		{"--principal=250000 --interest=6.5 --periods=360", "1566.01"},
+	} {
+		out, _, _ := runArgs(t, "--type=annuity --compounding=semiannual --show-exact "+c.loan)
+		if !strings.Contains(out, "("+c.exact+")!") {
+			t.Errorf("%s: %q, want %s", c.loan, out, c.exact)
+		}
+
+		monthly, _, _ := runArgs(t, "--type=annuity --show-exact "+c.loan)
+		if monthly == out {
+			t.Errorf("%s: the compounding made no difference", c.loan)
+		}
+	}
+
+	out, _, _ := runArgs(t, "--type=annuity --compounding=semiannual --principal=100000 --payment=581.60 --periods=300 --rate-precision=3")
+	if !strings.HasPrefix(out, "Your annual interest rate = 5.000%!") {
+		t.Errorf("solving for the rate: %q", out)
+	}
+}
+
+// TestSolverGivesUp forces the numeric solvers to stop before converging
+// and checks the error reports the residual they stopped at.
+func TestSolverGivesUp(t *testing.T) {
+	for _, args := range []string{
+		"--type=annuity --principal=1000000 --payment=21248 --periods=60",
+		"--type=annuity --principal=1000 --periods=12 --interest=5 --step-up=6:2",
+	} {
+		out, _, _ := runArgs(t, args+" --solver-max-iter=1")
+
+		var residual float64
+		if _, err := fmt.Sscanf(out, "The solver did not converge in 1 iterations, last residual %g\n", &residual); err != nil || residual <= 0 {
+			t.Errorf("%s: %v in %q", args, err, out)
+		}
+
+		if out, _, _ := runArgs(t, args); strings.Contains(out, "converge") {
+			t.Errorf("%s: %q by default", args, out)
+		}
+	}
+
+	if out, _, _ := runArgs(t, "--type=annuity --principal=1000000 --payment=21248 --periods=60 --solver-max-iter=0"); out != "Incorrect parameters\n" {
+		t.Errorf("no iterations: %q", out)
+	}
+}
+
+func TestEffectivePeriodicRate(t *testing.T) {
+	for _, c := range []struct {
+		nominal              float64
+		compounding, perYear int
+		want                 float64
+	}{
+		// compounded as often as paid, the rate is simply divided
+		{12, 12, 12, 0.01},
+		{6, 26, 26, 6.0 / 2600},
+		{4, 1, 1, 0.04},
+		{6, 2, 12, 0.004938622031196882},
+		{12, 1, 12, 0.009488792934583046},
+		{5, 52, 12, 0.004173349012439598},
+		{5, 365, 52, 0.000961934971713152},
+		{8, 4, 26, 0.0030512035198997367},
+		{10, 12, 26, 0.0038375613378391904},
+	} {
+		i := EffectivePeriodicRate(c.nominal, c.compounding, c.perYear)
+		if math.Abs(i-c.want) > 1e-15 {
+			t.Errorf("%g%% compounded %d times, paid %d times a year: %.18f, want %.18f", c.nominal, c.compounding, c.perYear, i, c.want)
+		}
+
+		if back := nominalAnnualRate(i, c.compounding, c.perYear); math.Abs(back-c.nominal) > 1e-9 {
+			t.Errorf("%g%% compounded %d times, paid %d times a year: back to %g%%", c.nominal, c.compounding, c.perYear, back)
+		}
+	}
+}
+
+func TestMonthlyRateUsed(t *testing.T) {
+	const loan = "--type=annuity --principal=10000 --periods=60 "
+
+	for _, rate := range []float64{10, 6, 4.75} {
+		got := verboseFigure(t, loan+fmt.Sprintf("--interest=%g", rate), "Monthly rate used = %g%%")
+		if want := rate / 1200 * 100; math.Abs(got-want) > 5e-7 {
+			t.Errorf("%g%%: monthly rate %g%%, want %g%%", rate, got, want)
+		}
+	}
+
+	// compounded quarterly, the monthly rate is a bit less than a twelfth
+	if got := verboseFigure(t, loan+"--interest=10 --compounding=quarterly", "Monthly rate used = %g%%"); got != 0.826484 {
+		t.Errorf("compounded quarterly: %g%%", got)
+	}
+}
+
+// TestInterestIsEAR takes 12% as an effective annual rate, whose monthly
+// rate compounds back to exactly 12% a year, below the nominal 1% a month.
+func TestInterestIsEAR(t *testing.T) {
+	const loan = "--type=annuity --principal=100000 --periods=12 --interest=12 "
+
+	i := math.Pow(1.12, 1.0/12) - 1
+	payment := math.Ceil(100000 * i / (1 - math.Pow(1+i, -12)))
+
+	for args, want := range map[string]float64{
+		"--interest-is-ear": payment,
+		"":                  8885,
+	} {
+		out, _, _ := runArgs(t, loan+args)
+		if !strings.HasPrefix(out, fmt.Sprintf("Your annuity payment = %g!\n", want)) {
+			t.Errorf("%q: %q, want a payment of %g", args, out, want)
+		}
+	}
+	if payment != 8857 {
+		t.Errorf("the effective rate pays %g", payment)
+	}
+
+	rate := verboseFigure(t, loan+"--interest-is-ear", "Monthly rate used = %g%%")
+	if math.Abs(rate-i*100) > 5e-7 {
+		t.Errorf("monthly rate %g%%, want %.6f%%", rate, i*100)
+	}
+
+	out, _, _ := runArgs(t, loan+"--interest-is-ear --explain")
+	if !strings.Contains(out, "\nEffective annual rate 12% = periodic rate 0.948879%\n") {
+		t.Errorf("the explanation doesn't convert the rate:\n%s", out)
+	}
+}
+
+// highPrecisionRates hold payments worked out independently, in 60-digit
+// decimal arithmetic, for rates with many decimals.
+var highPrecisionRates = []struct {
+	principal string
+	rate      string
+	periods   int
+	exact     string // the unrounded payment to the cent
+	payment   string // rounded up to whole units
+}{
+	{"1000000", "3.14159", 60, "18031.68", "18032"},
+	{"250000", "7.375", 360, "1726.69", "1727"},
+	{"800000", "12.3456789", 120, "11638.11", "11639"},
+	{"54321.5", "0.0123", 24, "2263.69", "2264"},
+	{"1000000", "4.99999", 240, "6599.55", "6600"},
+	{"3000000", "2.71828182845", 300, "13790.67", "13791"},
+}
+
+func TestHighPrecisionRates(t *testing.T) {
+	for _, c := range highPrecisionRates {
+		base := fmt.Sprintf("--type=annuity --principal=%s --interest=%s --periods=%d --show-exact", c.principal, c.rate, c.periods)
+		want := fmt.Sprintf("Your annuity payment = %s (%s)!", c.payment, c.exact)
+
+		for _, mode := range []string{"", " --exact", " --round-display-only"} {
+			out, _, _ := runArgs(t, base+mode)
+			if got, _, _ := strings.Cut(out, "\n"); got != want {
+				t.Errorf("%s%s: %q, want %q", base, mode, got, want)
+			}
+		}
+	}
+}
+
+// TestHighPrecisionRateSolved solves back for the rate from the unrounded
+// payment, shown to as many decimals as it was given with.
+func TestHighPrecisionRateSolved(t *testing.T) {
+	for _, c := range []struct{ args, want string }{
+		{"--principal=1000000 --payment=18031.68018254 --periods=60 --rate-precision=5", "3.14159%"},
+		{"--principal=250000 --payment=1726.687869 --periods=360 --rate-precision=3", "7.375%"},
+		{"--principal=800000 --payment=11638.10771639 --periods=120 --rate-precision=7", "12.3456789%"},
+	} {
+		out, _, _ := runArgs(t, "--type=annuity "+c.args)
+		if want := "Your annual interest rate = " + c.want + "!"; !strings.HasPrefix(out, want) {
+			t.Errorf("%s: %q, want %q", c.args, out, want)
+		}
+	}
+}
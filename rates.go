@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// applyRatesFile takes -interest for -product from -rates-file, a JSON
+// object of annual rates by product, unless -interest is given.
+func applyRatesFile() error {
+	if ratesFile == "" && product == "" {
+		return nil
+	}
+
+	if ratesFile == "" || product == "" {
+		return underSpecified("rates-file", "product")
+	}
+
+	if isProvided("interest") {
+		return nil
+	}
+
+	data, err := os.ReadFile(ratesFile)
+	if err != nil {
+		return fmt.Errorf(msg("rates-unreadable"), ratesFile, err)
+	}
+
+	var rates map[string]float64
+	if err := json.Unmarshal(data, &rates); err != nil {
+		return fmt.Errorf(msg("rates-unreadable"), ratesFile, err)
+	}
+
+	rate, ok := rates[product]
+	if !ok {
+		return fmt.Errorf(msg("rates-no-product"), product, ratesFile)
+	}
+
+	interest = rate
+	provided["interest"] = true
+	resolvedFlags["interest"] = formatFlagFloat(rate)
+
+	return nil
+}
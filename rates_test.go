@@ -0,0 +1,41 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRatesFile(t *testing.T) {
+	path := writeFile(t, "rates.json", `{"mortgage-30": 6, "auto-5": 9.5}`)
+	const loan = "--type=annuity --principal=200000 --periods=360 "
+
+	// the product's rate is used as -interest would be
+	looked, _, _ := runArgs(t, loan+"--rates-file="+path+" --product=mortgage-30")
+	if given, _, _ := runArgs(t, loan+"--interest=6"); looked != given || looked != "Your annuity payment = 1200!\nOverpayment = 232000\n" {
+		t.Errorf("looked up %q, given %q", looked, given)
+	}
+
+	// and -interest overrides it
+	overridden, _, _ := runArgs(t, loan+"--rates-file="+path+" --product=mortgage-30 --interest=9.5")
+	if given, _, _ := runArgs(t, loan+"--interest=9.5"); overridden != given {
+		t.Errorf("overridden %q, given %q", overridden, given)
+	}
+
+	for _, c := range []struct {
+		args, want string
+	}{
+		{"--rates-file=" + path + " --product=heloc", `No rate for "heloc" in ` + path},
+		{"--rates-file=" + filepath.Join(t.TempDir(), "none.json") + " --product=mortgage-30", "Cannot read the rates in "},
+		{"--rates-file=" + writeFile(t, "bad.json", `["6"]`) + " --product=mortgage-30", "Cannot read the rates in "},
+		{"--product=mortgage-30", "Incorrect parameters"},
+	} {
+		if out, _, _ := runArgs(t, loan+c.args); !strings.HasPrefix(out, c.want) {
+			t.Errorf("%s: %q, want %q", c.args, out, c.want)
+		}
+	}
+
+	if code, fields := jsonError(t, loan+"--product=mortgage-30"); code != "under-specified" || strings.Join(fields, ",") != "rates-file" {
+		t.Errorf("without a file: %s %v", code, fields)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import "fmt"
+
+// validRecasts checks that every -recast prepayment falls within the term.
+func validRecasts() bool {
+	for _, r := range recasts {
+		if r.month >= periods || r.value <= 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// recastStep is one -recast prepayment and the payment it recasts.
+type recastStep struct {
+	prepaid   Money
+	month     int
+	from, to  Money
+	remaining int
+}
+
+// recastSteps applies each -recast prepayment in turn and re-amortizes
+// the reduced balance over the rest of the term at a lower payment,
+// rejecting a prepayment that leaves nothing to recast.
+func recastSteps() ([]recastStep, error) {
+	if !validRecasts() {
+		return nil, outOfRange("recast")
+	}
+
+	savedPrincipal, savedPayment, savedPeriods := principal, payment, periods
+	defer func() { principal, payment, periods = savedPrincipal, savedPayment, savedPeriods }()
+
+	var steps = make([]recastStep, 0, len(recasts))
+	done := 0
+
+	for _, r := range recasts {
+		balance := remainingBalance(r.month-done) - r.value
+		if balance <= 0 {
+			return nil, outOfRange("recast")
+		}
+
+		old := payment
+		principal, periods = balance, periods-(r.month-done)
+		payment = getAmortizer().annuityPayment()
+		done = r.month
+
+		steps = append(steps, recastStep{moneyOf(r.value), r.month, moneyOf(old), moneyOf(payment), periods})
+	}
+
+	return steps, nil
+}
+
+// displayRecast shows the payment each prepayment recasts.
+func displayRecast(steps []recastStep) {
+	for _, s := range steps {
+		fmt.Fprintf(stdout, msg("recast"), s.prepaid, s.month, s.from, s.to, s.remaining)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+// TestRecast re-amortizes the balance left after each prepayment over the
+// rest of the term, worked out here from the annuity formula with the
+// payments rounded up as paid.
+func TestRecast(t *testing.T) {
+	const i = 0.06 / 12
+
+	annuity := func(p float64, n int) float64 {
+		return math.Ceil(p * i / (1 - math.Pow(1+i, float64(-n))))
+	}
+	balance := func(p, a float64, k int) float64 {
+		nk := math.Pow(1+i, float64(k))
+		return p*nk - a*(nk-1)/i
+	}
+
+	first := annuity(100000, 120)
+	left := balance(100000, first, 24) - 20000
+	second := annuity(left, 96)
+	third := annuity(balance(left, second, 36)-10000, 60)
+
+	out, _, _ := runArgs(t, "--type=annuity --principal=100000 --periods=120 --interest=6 --recast=24:20000,60:10000")
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("got %q", out)
+	}
+
+	for k, want := range []struct {
+		amount, month int
+		from, to      float64
+		remaining     int
+	}{
+		{20000, 24, first, second, 96},
+		{10000, 60, second, third, 60},
+	} {
+		var (
+			amount, month, remaining int
+			from, to                 float64
+		)
+		_, err := fmt.Sscanf(lines[2+k], "Prepaying %d after month %d recasts the payment from %g to %g for the remaining %d months",
+			&amount, &month, &from, &to, &remaining)
+		if err != nil || amount != want.amount || month != want.month || remaining != want.remaining ||
+			from != want.from || to != want.to {
+			t.Errorf("%v in %q, want %g to %g", err, lines[2+k], want.from, want.to)
+		}
+	}
+
+	// a prepayment of the whole balance leaves nothing to recast
+	if out, _, _ := runArgs(t, "--type=annuity --principal=100000 --periods=120 --interest=6 --recast=24:200000"); out != "Incorrect parameters\n" {
+		t.Errorf("an overlarge prepayment: %q", out)
+	}
+
+	// nor does one at the end of the term, rejected before any output
+	if code, fields := jsonError(t, "--type=annuity --principal=100000 --periods=120 --interest=6 --recast=120:1000"); code != "out-of-range" || fmt.Sprint(fields) != "[recast]" {
+		t.Errorf("a prepayment in the last month: %s %v", code, fields)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// doRefinanceCalculations compares the current loan with one at
+// -new-interest and finds the month its savings cover the closing costs.
+func doRefinanceCalculations() error {
+	if !isProvided("principal", "periods", "interest") {
+		return underSpecified("principal", "periods", "interest")
+	}
+
+	if periods <= 0 {
+		return outOfRange("periods")
+	}
+
+	if closingCosts < 0 {
+		return outOfRange("closing-costs")
+	}
+
+	current := getAmortizer().annuityPayment()
+
+	rate := interest
+	interest = newInterest
+	refinanced := getAmortizer().annuityPayment()
+	interest = rate
+
+	saving := moneyOf(current).Sub(moneyOf(refinanced))
+
+	fmt.Fprintf(stdout, msg("refinance-current"), moneyOf(current))
+	fmt.Fprintf(stdout, msg("refinance-new"), moneyOf(refinanced))
+	fmt.Fprintf(stdout, msg("refinance-saving"), saving)
+
+	if moneyOf(closingCosts) == 0 && saving >= 0 {
+		fmt.Fprintln(stdout, msg("refinance-no-costs"))
+		return nil
+	}
+
+	if saving <= 0 {
+		fmt.Fprintf(stdout, msg("refinance-never"), moneyOf(closingCosts))
+		return nil
+	}
+
+	month := int(math.Ceil(float64(moneyOf(closingCosts)) / float64(saving)))
+	if month > periods {
+		fmt.Fprintf(stdout, msg("refinance-never"), moneyOf(closingCosts))
+		return nil
+	}
+
+	fmt.Fprintf(stdout, msg("refinance-break-even"), moneyOf(closingCosts), month)
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRefinanceBreakEven(t *testing.T) {
+	// 194 a month at 6% against 185 at 4% saves 9
+	for _, c := range []struct{ args, want string }{
+		{"--closing-costs=500", "The closing costs of 500 are recouped in month 56\n"},
+		{"--closing-costs=540", "The closing costs of 540 are recouped in month 60\n"},
+		{"--closing-costs=541", "The closing costs of 541 are never recouped\n"},
+		{"", "Without closing costs the refinanced loan breaks even immediately\n"},
+		{"--closing-costs=0", "Without closing costs the refinanced loan breaks even immediately\n"},
+	} {
+		out, _, _ := runArgs(t, "--principal=10000 --periods=60 --interest=6 --new-interest=4 "+c.args)
+		if !strings.HasSuffix(out, c.want) {
+			t.Errorf("%s: %q, want %q", c.args, out, c.want)
+		}
+	}
+
+	out, _, _ := runArgs(t, "--principal=10000 --periods=60 --interest=4 --new-interest=6")
+	if !strings.HasSuffix(out, "The closing costs of 0 are never recouped\n") {
+		t.Errorf("a dearer loan without costs: %q", out)
+	}
+}
+
+// TestRefinanceErrors names the flag at fault, as the JSON errors would,
+// though the refinance table is only printed as text.
+func TestRefinanceErrors(t *testing.T) {
+	for _, c := range []struct{ args, want string }{
+		{"--principal=10000 --interest=6 --new-interest=4", "under-specified [periods]"},
+		{"--principal=10000 --periods=0 --interest=6 --new-interest=4", "out-of-range [periods]"},
+		{"--principal=10000 --periods=60 --interest=6 --new-interest=4 --closing-costs=-5", "out-of-range [closing-costs]"},
+	} {
+		parseFlags(t, c.args)
+
+		var pe paramError
+		if err := doRefinanceCalculations(); !errors.As(err, &pe) || fmt.Sprint(pe.code, " ", pe.fields) != c.want {
+			t.Errorf("%s: %v, want %s", c.args, err, c.want)
+		}
+	}
+}
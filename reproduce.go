@@ -0,0 +1,76 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// resolvedFlags are the values a calculation worked out for flags it
+// wasn't given, such as the -interest of a -product, for -reproduce to
+// spell out.
+var resolvedFlags map[string]string
+
+// givenFlags are the flags given as they would be typed, taken before the
+// calculation changes any of their values.
+func givenFlags(fs *flag.FlagSet) map[string]string {
+	var given = make(map[string]string)
+
+	fs.Visit(func(f *flag.Flag) {
+		// the -input-json keys are set as flags of their own
+		if f.Name != "reproduce" && f.Name != "input-json" {
+			given[f.Name] = flagValue(f)
+		}
+	})
+
+	return given
+}
+
+// canonicalCommand spells out the given flags and the resolved ones in a
+// single, sorted command line that repeats the invocation.
+func canonicalCommand(given map[string]string) string {
+	var values = make(map[string]string, len(given)+len(resolvedFlags))
+	var names = make([]string, 0, len(given)+len(resolvedFlags))
+
+	for _, flags := range []map[string]string{resolvedFlags, given} {
+		for name, v := range flags {
+			if _, ok := values[name]; !ok {
+				names = append(names, name)
+			}
+			values[name] = v
+		}
+	}
+	sort.Strings(names)
+
+	var parts = []string{filepath.Base(os.Args[0])}
+
+	for _, name := range names {
+		v := values[name]
+		if strings.ContainsAny(v, " \t\n'\"$`\\*?[]{}();&|<>#~!") || v == "" {
+			v = shellQuote(v)
+		}
+
+		parts = append(parts, "--"+name+"="+v)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// flagValue is the value of a flag as it would be typed, without the
+// exponent notation of large floats.
+func flagValue(f *flag.Flag) string {
+	if g, ok := f.Value.(flag.Getter); ok {
+		if x, ok := g.Get().(float64); ok {
+			return formatFlagFloat(x)
+		}
+	}
+
+	return f.Value.String()
+}
+
+func formatFlagFloat(x float64) string {
+	return strconv.FormatFloat(x, 'f', -1, 64)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestReproduce checks that the echoed command names every value given or
+// defaulted, in a canonical order, and that running it repeats the result.
+func TestReproduce(t *testing.T) {
+	for _, c := range []struct{ args, want string }{
+		{"--type=annuity --principal=1m --periods=60 --interest=10",
+			"--interest=10 --periods=60 --principal=1000000 --start-date=2024-01-15 --type=annuity"},
+		{"--interest=10 --type=annuity --payment=21248 --principal=1000000",
+			"--interest=10 --payment=21248 --principal=1000000 --start-date=2024-01-15 --type=annuity"},
+		{"--type=diff --principal=500000 --periods=3 --interest=7.8 --lang=de",
+			"--interest=7.8 --lang=de --periods=3 --principal=500000 --start-date=2024-01-15 --type=diff"},
+	} {
+		out, _, _ := runArgs(t, c.args+" --reproduce")
+
+		lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+		_, flags, _ := strings.Cut(lines[len(lines)-1], " ")
+		if flags != c.want {
+			t.Errorf("%s: echoed %q, want %q", c.args, flags, c.want)
+			continue
+		}
+
+		result := strings.Join(lines[:len(lines)-1], "\n") + "\n"
+		if again, _, _ := runArgs(t, flags); again != result {
+			t.Errorf("%s: the echoed command printed\n%sinstead of\n%s", c.args, again, result)
+		}
+	}
+}
+
+// TestReproduceResolved checks that the command spells out the values
+// worked out from -rates-file and -input-json, and leaves JSON on stdout
+// for a program to read.
+func TestReproduceResolved(t *testing.T) {
+	rates := writeFile(t, "rates.json", `{"mortgage": 6.5}`)
+	out, _, _ := runArgs(t, "--type=annuity --principal=100000 --periods=120 --rates-file="+rates+" --product=mortgage --reproduce")
+	if !strings.Contains(out, " --interest=6.5 --periods=120 ") {
+		t.Errorf("the resolved rate isn't spelled out:\n%s", out)
+	}
+
+	pipeStdin(t, `{"type": "annuity", "principal": 100000, "periods": 120, "interest": 6}`)
+	out, errOut, _ := runArgs(t, "--input-json=- --reproduce")
+
+	var r Result
+	if err := json.Unmarshal([]byte(out), &r); err != nil {
+		t.Fatalf("%v in %s", err, out)
+	}
+
+	want := " --format=json --interest=6 --periods=120 --principal=100000 --start-date=2024-01-15 --type=annuity\n"
+	if !strings.HasSuffix(errOut, want) || strings.Contains(errOut, "input-json") {
+		t.Fatalf("stderr %q, want it to end in %q", errOut, want)
+	}
+
+	_, flags, _ := strings.Cut(strings.TrimSuffix(errOut, "\n"), " ")
+	if again, _, _ := runArgs(t, flags); again != out {
+		t.Errorf("the echoed command printed\n%sinstead of\n%s", again, out)
+	}
+}
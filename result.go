@@ -0,0 +1,76 @@
+package main
+
+import (
+	"io"
+	"text/template"
+)
+
+// schemaVersion is the "schema_version" of all JSON output. It goes up
+// whenever a field is renamed, removed or changes its type; adding a field
+// leaves it alone.
+const schemaVersion = 1
+
+// Result holds the figures of a finished calculation, as seen by --template
+// and the --format writers.
+//
+// Overpayment counts every one of the periods at the full payment, as the
+// output always has, while the schedule reduces the final payment to what
+// clears the balance. ScheduledOverpayment is what the schedule pays over the
+// principal, which the cumulative_interest of its last row comes to, draw
+// interest aside. The two differ by the rounding residual --verbose shows,
+// or for diff payments by the overpayment being rounded up to a whole unit.
+type Result struct {
+	SchemaVersion        int           `json:"schema_version"`
+	Payment              Money         `json:"payment"`
+	Principal            Money         `json:"principal"`
+	Periods              int           `json:"periods"`
+	Interest             float64       `json:"interest"`
+	Overpayment          Money         `json:"overpayment"`
+	ScheduledOverpayment *Money        `json:"scheduled_overpayment,omitempty"`
+	Schedule             []ScheduleRow `json:"schedule,omitempty"`
+}
+
+func newResult(overpayment Money, schedule []ScheduleRow) Result {
+	r := Result{
+		SchemaVersion: schemaVersion,
+		Payment:       moneyOf(payment),
+		Principal:     moneyOf(principal),
+		Periods:       periods,
+		Interest:      interest,
+		Overpayment:   overpayment,
+		Schedule:      schedule,
+	}
+
+	if schedule != nil {
+		scheduled := scheduledOverpayment(schedule)
+		r.ScheduledOverpayment = &scheduled
+	}
+
+	return r
+}
+
+// templateValue is a flag.Value parsing its text/template as soon as the
+// flag is set, so a broken template is reported along with other flag errors.
+type templateValue struct {
+	text string
+	tmpl *template.Template
+}
+
+func (tv *templateValue) String() string {
+	return tv.text
+}
+
+func (tv *templateValue) Set(s string) error {
+	tmpl, err := template.New("output").Parse(s)
+	if err != nil {
+		return err
+	}
+
+	tv.text, tv.tmpl = s, tmpl
+
+	return nil
+}
+
+func (tv *templateValue) render(w io.Writer, r Result) error {
+	return tv.tmpl.Execute(w, r)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestTemplate(t *testing.T) {
+	out, _, _ := runArgv(t, "--type=annuity", "--principal=1000", "--periods=12", "--interest=12",
+		"--template=Pay {{.Payment}} for {{.Periods}} months, {{.Overpayment}} over\n")
+	if out != "Pay 89 for 12 months, 68 over\n" {
+		t.Errorf("rendered %q", out)
+	}
+
+	out, _, _ = runArgv(t, "--type=annuity", "--principal=1000", "--periods=3", "--interest=12",
+		"--template={{range .Schedule}}{{.Month}}:{{.Balance}} {{end}}")
+	if out != "1:669 2:334.69 3:0 " {
+		t.Errorf("rendered the schedule as %q", out)
+	}
+
+	// a malformed template is a flag error, before anything is calculated
+	out, errOut, code := runArgv(t, "--type=annuity", "--principal=1000", "--periods=12", "--interest=12", "--template={{.Payment")
+	if code != 2 || out != "" || !strings.Contains(errOut, `invalid value "{{.Payment" for flag -template`) {
+		t.Errorf("exit %d, %q, %q", code, out, errOut)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	for _, c := range []struct{ query, want string }{
+		{"overpayment", "274880\n"},
+		{"payment", "21248\n"},
+		{"month[0].interest_portion", "8333.33\n"},
+		{"month[59].balance", "0\n"},
+		{"schedule[2].payment", "21248\n"},
+		{"month[60].balance", "Index 60 is out of range for schedule of 60 items\n"},
+		{"month[-1].balance", "Invalid query \"month[-1]\"\n"},
+		{"month[a]", "Invalid query \"month[a]\"\n"},
+		{"nope", "Unknown field \"nope\"\n"},
+		{"month[0].nope", "Unknown field \"nope\"\n"},
+	} {
+		if out, _, _ := runArgs(t, "--type=annuity --principal=1000000 --periods=60 --interest=10 --query="+c.query); out != c.want {
+			t.Errorf("%s: %q, want %q", c.query, out, c.want)
+		}
+	}
+}
+
+// TestSchemaVersion checks the schema_version of the JSON output of every
+// calculation with one, and of the errors of those without.
+func TestSchemaVersion(t *testing.T) {
+	for _, args := range []string{
+		"--type=annuity --principal=1000 --periods=12 --interest=5",
+		"--type=annuity --principal=1000 --payment=100 --interest=5",
+		"--type=annuity --payment=100 --periods=12 --interest=5",
+		"--type=annuity --principal=1000 --payment=100 --periods=12",
+		"--type=annuity --periods=12 --interest=5 --max-overpayment=50",
+		"--type=annuity --principal=1000 --interest=5 --max-overpayment=50",
+		"--type=diff --principal=1000 --periods=12 --interest=5",
+		"--interest-only --principal=1000 --periods=12 --interest=5",
+		"--interest-only-months=3 --principal=1000 --periods=12 --interest=5",
+		"--type=annuity --principal=1000 --periods=0 --interest=5",
+		"--type=annuity --principal=1000 --periods=12 --interest=5 --new-interest=3",
+		"--type=annuity --principal=1000 --periods=12 --interest=5 --compare-rounding",
+		"--principal=1000 --rate-sweep=4,5 --terms=12",
+		"--budget=2000 --periods=360 --interest=6",
+	} {
+		out, _, _ := runArgs(t, args+" --format=json")
+
+		var r struct {
+			SchemaVersion *int `json:"schema_version"`
+		}
+		if err := json.Unmarshal([]byte(out), &r); err != nil || r.SchemaVersion == nil || *r.SchemaVersion != schemaVersion {
+			t.Errorf("%s: %v, version %v in %s", args, err, r.SchemaVersion, out)
+		}
+	}
+}
+
+// TestTextOnlyModes checks that the calculations printing only tables
+// refuse the output flags they can't honor instead of ignoring them.
+func TestTextOnlyModes(t *testing.T) {
+	for _, args := range []string{
+		"--type=annuity --principal=1000 --periods=12 --interest=5 --new-interest=3 --format=json",
+		"--principal=1000 --rate-sweep=4,5 --terms=12 --format=text,json:out.json",
+		"--type=annuity --principal=1000 --periods=12 --interest=5 --compare-frequency --query=payment",
+		"--budget=2000 --periods=360 --interest=6 --format=csv",
+	} {
+		out, _, _ := runArgs(t, args)
+		if !strings.Contains(out, "only prints text") && !strings.Contains(out, `"code":"text-only"`) {
+			t.Errorf("%s: %s", args, out)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"text/tabwriter"
+)
+
+// roundingPolicies are the -round values, in the order -compare-rounding
+// shows them.
+var roundingPolicies = []string{"ceil", "floor", "nearest", "half-even", "none"}
+
+func validRounding(policy string) bool {
+	if policy == "" {
+		return true
+	}
+
+	for _, p := range roundingPolicies {
+		if p == policy {
+			return true
+		}
+	}
+
+	return false
+}
+
+// paymentMayRoundDown reports whether the rounded payment can fall short of
+// the exact one, leaving a larger final payment to clear the balance.
+func paymentMayRoundDown() bool {
+	if roundPolicy == "" {
+		return favor == "borrower"
+	}
+
+	return roundPolicy == "floor" || roundPolicy == "nearest" || roundPolicy == "half-even"
+}
+
+func roundNearest(v float64) float64 {
+	if displayRounding {
+		return v
+	}
+
+	u := moneyUnit()
+
+	return math.Round(v*100/float64(u)) * float64(u) / 100
+}
+
+// roundHalfEven is roundNearest with halves rounded to the even neighbor,
+// which unlike rounding them up doesn't bias a sum of many amounts.
+func roundHalfEven(v float64) float64 {
+	if displayRounding {
+		return v
+	}
+
+	u := moneyUnit()
+
+	return math.RoundToEven(v*100/float64(u)) * float64(u) / 100
+}
+
+// roundByPolicy rounds a computed payment by -round, or by the given
+// function without it.
+func roundByPolicy(v float64, byDefault func(float64) float64) float64 {
+	switch roundPolicy {
+	case "ceil":
+		return roundUp(v)
+	case "floor":
+		return roundDown(v)
+	case "nearest":
+		return roundNearest(v)
+	case "half-even":
+		return roundHalfEven(v)
+	case "none":
+		return v
+	}
+
+	return byDefault(v)
+}
+
+func exactRoundNearest(r *big.Rat) *big.Rat {
+	if displayRounding {
+		return r
+	}
+
+	u := exactUnit()
+
+	return ratMul(ratFloor(ratAdd(ratQuo(r, u), big.NewRat(1, 2))), u)
+}
+
+func exactRoundHalfEven(r *big.Rat) *big.Rat {
+	if displayRounding {
+		return r
+	}
+
+	u := exactUnit()
+	q := ratQuo(r, u)
+	n := ratFloor(q)
+
+	switch ratSub(q, n).Cmp(big.NewRat(1, 2)) {
+	case 1:
+		n = ratAdd(n, ratInt(1))
+	case 0:
+		if new(big.Int).Rem(n.Num(), big.NewInt(2)).Sign() != 0 {
+			n = ratAdd(n, ratInt(1))
+		}
+	}
+
+	return ratMul(n, u)
+}
+
+// exactRoundByPolicy is roundByPolicy for the exact arithmetic.
+func exactRoundByPolicy(r *big.Rat, byDefault func(*big.Rat) *big.Rat) *big.Rat {
+	switch roundPolicy {
+	case "ceil":
+		return exactRoundUp(r)
+	case "floor":
+		return exactRoundDown(r)
+	case "nearest":
+		return exactRoundNearest(r)
+	case "half-even":
+		return exactRoundHalfEven(r)
+	case "none":
+		return r
+	}
+
+	return byDefault(r)
+}
+
+// doRoundingComparison computes the loan under every rounding policy, for
+// -compare-rounding.
+func doRoundingComparison() error {
+	if !isProvided("principal", "periods", "interest") {
+		return underSpecified("principal", "periods", "interest")
+	}
+
+	if periods <= 0 {
+		return outOfRange("periods")
+	}
+
+	saved := roundPolicy
+	defer func() { roundPolicy = saved }()
+
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, msg("compare-rounding-header"))
+
+	for _, roundPolicy = range roundingPolicies {
+		var first, overpayment Money
+
+		if method == "diff" {
+			payments := getAmortizer().diffPayments()
+			first, overpayment = moneyOf(payments[0]), diffOverpayment(payments)
+		} else {
+			payment = getAmortizer().annuityPayment()
+			first, overpayment = moneyOf(payment), calculateOverpayment()
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t\n", roundPolicy, first, overpayment)
+	}
+
+	return w.Flush()
+}
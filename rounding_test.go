@@ -0,0 +1,77 @@
+package main
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+)
+
+// TestCompareRounding checks the overpayment of a loan whose exact payment
+// of 21247.04 rounds down to the nearest unit: it pays a little more
+// interest than the exact payment, and both less than the payment rounded
+// up. A payment rounding up to the nearest unit would repay the loan faster
+// and overpay less than the exact one instead.
+func TestCompareRounding(t *testing.T) {
+	out, _, _ := runArgs(t, "--type=annuity --principal=1000000 --periods=60 --interest=10 --compare-rounding")
+
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	if len(lines) != len(roundingPolicies)+1 {
+		t.Fatalf("got\n%s", out)
+	}
+
+	overpayment := map[string]Money{}
+	for k, line := range lines[1:] {
+		f := strings.Fields(line)
+		if len(f) != 3 || f[0] != roundingPolicies[k] {
+			t.Fatalf("row %q", line)
+		}
+
+		var m Money
+		if err := m.UnmarshalJSON([]byte(f[2])); err != nil {
+			t.Fatalf("%v in %q", err, line)
+		}
+		overpayment[f[0]] = m
+	}
+
+	if none, nearest, ceil := overpayment["none"], overpayment["nearest"], overpayment["ceil"]; none > nearest || nearest > ceil {
+		t.Errorf("none %s, nearest %s, ceil %s", none, nearest, ceil)
+	}
+	// rounding up is the default
+	if out, _, _ := runArgs(t, "--type=annuity --principal=1000000 --periods=60 --interest=10"); !strings.HasSuffix(out, "Overpayment = "+overpayment["ceil"].String()+"\n") {
+		t.Errorf("ceil overpays %s, the default %q", overpayment["ceil"], out)
+	}
+}
+
+// TestRoundHalfEven rounds the halves 0.5 to 99.5 to their even neighbors,
+// which cancels out where rounding them all up is biased by 0.5 each.
+func TestRoundHalfEven(t *testing.T) {
+	for _, c := range []struct {
+		v, want float64
+	}{
+		{0.5, 0}, {1.5, 2}, {2.5, 2}, {3.5, 4}, {-2.5, -2}, {2.49, 2}, {2.51, 3},
+	} {
+		if got := roundHalfEven(c.v); got != c.want {
+			t.Errorf("%g rounds to %g, want %g", c.v, got, c.want)
+		}
+		if got, _ := exactRoundHalfEven(new(big.Rat).SetFloat64(c.v)).Float64(); got != c.want {
+			t.Errorf("exactly, %g rounds to %g, want %g", c.v, got, c.want)
+		}
+	}
+
+	var halfEven, halfUp float64
+	for k := 0; k < 100; k++ {
+		v := float64(k) + 0.5
+		halfEven += roundHalfEven(v) - v
+		halfUp += roundNearest(v) - v
+	}
+	if halfEven != 0 || halfUp != 50 {
+		t.Errorf("half-even is biased by %g, half-up by %g", halfEven, halfUp)
+	}
+
+	// in cents the halves are half a cent, here exact in binary
+	centsMode = true
+	defer func() { centsMode = false }()
+	if a, b := roundHalfEven(0.125), roundHalfEven(0.375); a != 0.12 || b != 0.38 {
+		t.Errorf("in cents 0.125 rounds to %g, 0.375 to %g", a, b)
+	}
+}
@@ -0,0 +1,322 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"text/tabwriter"
+)
+
+// ScheduleRow is a single month of the repayment schedule.
+type ScheduleRow struct {
+	Month               int   `json:"month"`
+	Payment             Money `json:"payment"`
+	InterestPortion     Money `json:"interest_portion"`
+	PrincipalPortion    Money `json:"principal_portion"`
+	Balance             Money `json:"balance"`
+	CumulativeInterest  Money `json:"cumulative_interest"`
+	CumulativePrincipal Money `json:"cumulative_principal"`
+}
+
+// newScheduleRow fills in the running totals from the previous row.
+func newScheduleRow(rows []ScheduleRow, month int, paid, interest, balance Money) ScheduleRow {
+	r := ScheduleRow{
+		Month:            month,
+		Payment:          paid,
+		InterestPortion:  interest,
+		PrincipalPortion: paid.Sub(interest),
+		Balance:          balance,
+	}
+
+	if len(rows) > 0 {
+		r.CumulativeInterest = rows[len(rows)-1].CumulativeInterest
+		r.CumulativePrincipal = rows[len(rows)-1].CumulativePrincipal
+	}
+
+	r.CumulativeInterest = r.CumulativeInterest.Add(r.InterestPortion)
+	r.CumulativePrincipal = r.CumulativePrincipal.Add(r.PrincipalPortion)
+
+	return r
+}
+
+func annuitySchedule() []ScheduleRow {
+	return addStubPayment(getAmortizer().annuitySchedule(moneyOf(payment)))
+}
+
+// maxScheduleMonths bounds a schedule whose term isn't fixed, in case the
+// payment barely covers the interest.
+const maxScheduleMonths = 1200
+
+// scheduleCapacity is the number of rows to allocate for a schedule of
+// -periods months, bounded in case the term came out of a calculation.
+func scheduleCapacity() int {
+	return min(max(periods, 0), maxScheduleMonths)
+}
+
+// buildAnnuitySchedule pays the given amount every month, with the last
+// one reduced to whatever clears the balance down to -residual. The
+// amortizers charge the interest on each balance, to the cent.
+func buildAnnuitySchedule(amount Money, interestOn func(balance Money) Money) []ScheduleRow {
+	var rows = make([]ScheduleRow, 0, scheduleCapacity())
+
+	balance := moneyOf(principal)
+	left := moneyOf(residual)
+
+	// skipped payments capitalize their interest, so the term runs on
+	// until the balance is cleared
+	last := periods
+	if len(skipMonths) > 0 {
+		last = max(periods, maxScheduleMonths)
+	}
+
+	for m := 1; m <= last && balance > left; m++ {
+		interest := interestOn(balance)
+		paid := steppedAmount(amount, m)
+
+		if skipMonths[m] {
+			paid = 0
+		} else if due := balance.Add(interest).Sub(left); m == last || paid > due {
+			paid = due
+		}
+
+		balance = balance.Sub(paid.Sub(interest))
+		rows = append(rows, newScheduleRow(rows, m, paid, interest, balance))
+	}
+
+	return rows
+}
+
+// diffSchedule splits each differentiated payment into the fixed principal
+// part and the rest, which is the interest.
+func diffSchedule(payments []float64) []ScheduleRow {
+	var rows = make([]ScheduleRow, 0, len(payments))
+
+	balance := moneyOf(principal)
+	pn := moneyOf(principal / float64(len(payments)))
+
+	for m, dp := range payments {
+		part := pn
+		if m == len(payments)-1 {
+			part = balance
+		}
+
+		paid := moneyOf(dp)
+		balance = balance.Sub(part)
+		rows = append(rows, newScheduleRow(rows, m+1, paid, paid.Sub(part), balance))
+	}
+
+	return addStubPayment(rows)
+}
+
+func scheduleTotals(rows []ScheduleRow) (paid, interest Money) {
+	for _, r := range rows {
+		paid = paid.Add(r.Payment)
+		interest = interest.Add(r.InterestPortion)
+	}
+
+	return paid, interest
+}
+
+// scheduledOverpayment is what the rows pay beyond the principal they
+// repay, with the interest of any draw period.
+func scheduledOverpayment(rows []ScheduleRow) Money {
+	paid, _ := scheduleTotals(rows)
+
+	return paid.Sub(loanPrincipal()).Add(moneyOf(residual)).Add(moneyOf(calculateDrawInterest()))
+}
+
+// reconcileSchedule compares the sum of the printed payments with the
+// principal plus the stated overpayment.
+func reconcileSchedule(rows []ScheduleRow, overpayment Money) error {
+	paid, _ := scheduleTotals(rows)
+	stated := loanPrincipal().Add(overpayment)
+
+	fmt.Fprintf(stdout, msg("reconcile"), paid, stated)
+
+	if diff := paid.Sub(stated); diff > 1 || diff < -1 {
+		return fmt.Errorf(msg("reconcile-failed"), diff)
+	}
+
+	return nil
+}
+
+// displayCrossover finds the first month in which the principal part of
+// the payment exceeds the interest part. Diff payments repay the same
+// principal every month while the interest falls, so it's usually month 1.
+func displayCrossover(rows []ScheduleRow) {
+	for _, r := range rows {
+		if r.PrincipalPortion > r.InterestPortion {
+			fmt.Fprintf(stdout, msg("crossover"), r.Month)
+			if method == "diff" && r.Month == 1 {
+				fmt.Fprintln(stdout, msg("crossover-diff"))
+			}
+			return
+		}
+	}
+
+	fmt.Fprintln(stdout, msg("no-crossover"))
+}
+
+// displaySkippedMonths counts the skipped months the schedule reached, as
+// the balance may be cleared before a late one.
+func displaySkippedMonths(rows []ScheduleRow) {
+	var skipped int
+	for _, row := range rows {
+		if skipMonths[row.Month] {
+			skipped++
+		}
+	}
+
+	if skipped == 1 {
+		fmt.Fprintf(stdout, msg("skip-month"), formatPeriods(len(rows)))
+		return
+	}
+	fmt.Fprintf(stdout, msg("skip-months"), skipped, formatPeriods(len(rows)))
+}
+
+// displayLastPayment shows the final payment when it differs from the
+// others, as under -favor=borrower.
+func displayLastPayment(rows []ScheduleRow) {
+	if len(rows) == 0 || rows[len(rows)-1].Payment == moneyOf(payment) {
+		return
+	}
+
+	fmt.Fprintf(stdout, msg("final-payment"), rows[len(rows)-1].Payment, moneyOf(payment))
+}
+
+// displayRoundingResidual shows how much more than the schedule the
+// overpayment counts, by taking every payment in full while the final one
+// is reduced to clear the balance.
+func displayRoundingResidual(rows []ScheduleRow) {
+	extra := unreducedPayments(rows)
+	if extra <= 0 || len(rows) == 0 {
+		return
+	}
+
+	if len(rows) < periods {
+		fmt.Fprintf(stdout, msg("rounding-residual-early"), extra, formatPeriods(paymentMonths(len(rows))))
+		return
+	}
+
+	fmt.Fprintf(stdout, msg("rounding-residual"), extra, rows[len(rows)-1].Payment)
+}
+
+// unreducedPayments is how much more the overpayment counts as paid than
+// the schedule pays, by taking the reduced final payment in full.
+func unreducedPayments(rows []ScheduleRow) Money {
+	paid, _ := scheduleTotals(rows)
+	counted := calculateOverpayment().Add(loanPrincipal()).Sub(moneyOf(residual)).Sub(moneyOf(calculateDrawInterest()))
+
+	return counted.Sub(paid)
+}
+
+func displayExtraMonthly(base []ScheduleRow) {
+	extra := moneyOf(extraMonthly)
+	faster := getAmortizer().annuitySchedule(moneyOf(payment).Add(extra))
+
+	_, baseInterest := scheduleTotals(base)
+	_, interest := scheduleTotals(faster)
+
+	fmt.Fprintf(stdout, msg("extra-monthly"), extra, formatPeriods(len(faster)), baseInterest.Sub(interest))
+}
+
+// displayRoundedPayment pays the payment rounded up to the next multiple
+// of -round-payment-up-to and shows the shorter term it gives.
+func displayRoundedPayment(base []ScheduleRow) {
+	step := moneyOf(roundPaymentUpTo)
+	rounded := Money(math.Ceil(float64(moneyOf(payment))/float64(step))) * step
+	faster := getAmortizer().annuitySchedule(rounded)
+
+	_, baseInterest := scheduleTotals(base)
+	_, interest := scheduleTotals(faster)
+
+	if len(faster) == len(base) {
+		fmt.Fprintf(stdout, msg("round-payment-same"), rounded, baseInterest.Sub(interest))
+		return
+	}
+
+	fmt.Fprintf(stdout, msg("round-payment-up"), rounded, formatPeriods(len(faster)), formatPeriods(len(base)-len(faster)),
+		baseInterest.Sub(interest))
+}
+
+// displayCompactSchedule prints a line for each run of equal payments,
+// which for diff payments is usually every month.
+func displayCompactSchedule(rows []ScheduleRow) {
+	fmt.Fprintln(stdout)
+
+	for start := 0; start < len(rows); {
+		end := start
+		for end+1 < len(rows) && rows[end+1].Payment == rows[start].Payment {
+			end++
+		}
+
+		if end == start {
+			fmt.Fprintf(stdout, msg("compact-month"), rows[start].Month, rows[start].Payment)
+		} else {
+			fmt.Fprintf(stdout, msg("compact-months"), rows[start].Month, rows[end].Month, rows[start].Payment)
+		}
+
+		start = end + 1
+	}
+}
+
+func displaySchedule(rows []ScheduleRow) {
+	fmt.Fprintln(stdout)
+	writeSchedule(stdout, rows)
+}
+
+// displayReverseSchedule counts down from the last month to the first, with
+// the balance as what's left to pay.
+func displayReverseSchedule(rows []ScheduleRow) {
+	reversed := make([]ScheduleRow, len(rows))
+	for k, r := range rows {
+		reversed[len(rows)-1-k] = r
+	}
+
+	fmt.Fprintln(stdout)
+	writeScheduleRows(stdout, msg("schedule-reverse-header"), reversed)
+}
+
+func writeSchedule(out io.Writer, rows []ScheduleRow) error {
+	return writeScheduleRows(out, msg("schedule-header"), rows)
+}
+
+func writeScheduleRows(out io.Writer, header string, rows []ScheduleRow) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+
+	fmt.Fprintln(w, header)
+
+	for _, r := range rows {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t\n", r.Month, r.Payment, r.InterestPortion, r.PrincipalPortion, r.Balance)
+	}
+
+	return w.Flush()
+}
+
+func displayExplanation(rows []ScheduleRow, overpayment Money) {
+	paid, interest := scheduleTotals(rows)
+	draw := moneyOf(calculateDrawInterest())
+
+	fmt.Fprintln(stdout)
+	if interestIsEAR {
+		displayEffectiveRate()
+	}
+	fmt.Fprintf(stdout, msg("explain-principal"), paid.Sub(interest))
+	fmt.Fprintf(stdout, msg("explain-interest"), interest, len(rows))
+	if draw > 0 {
+		fmt.Fprintf(stdout, msg("explain-draw"), draw)
+	}
+	fmt.Fprintf(stdout, msg("explain-total"), paid.Add(draw))
+
+	gap := overpayment.Sub(interest).Sub(draw)
+
+	// the schedule reduces the final payment the overpayment takes in full
+	if unreduced := unreducedPayments(rows); unreduced > 0 && len(rows) > 0 {
+		fmt.Fprintf(stdout, msg("explain-unreduced"), unreduced, rows[len(rows)-1].Payment)
+		gap = gap.Sub(unreduced)
+	}
+
+	if gap != 0 {
+		fmt.Fprintf(stdout, msg("explain-rounding"), gap)
+	}
+}
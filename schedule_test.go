@@ -0,0 +1,465 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestExplain checks that the --explain breakdown sums the interest column
+// of the schedule, and that the overpayment differs from it by less than
+// a payment, the rounding shortening only the final one, or not at all
+// when it's counted from the schedule.
+func TestExplain(t *testing.T) {
+	for _, c := range []struct {
+		args  string
+		exact bool
+	}{
+		{"--type=annuity --principal=1000000 --periods=60 --interest=10", false},
+		{"--type=annuity --principal=1000000 --periods=60 --interest=10 --round=none", false},
+		{"--type=annuity --principal=1000000 --periods=60 --interest=10 --reduce-final-payment", true},
+		{"--type=annuity --principal=300000 --periods=240 --interest=6 --disbursements=1:100000,4:100000,7:100000", false},
+		{"--type=diff --principal=1000000 --periods=120 --interest=6", true},
+		{"--type=diff --principal=1000 --periods=7 --interest=12", true},
+	} {
+		out, _, _ := runArgs(t, c.args+" --format=json")
+
+		var r Result
+		if err := json.Unmarshal([]byte(out), &r); err != nil {
+			t.Fatalf("%s: %v in %s", c.args, err, out)
+		}
+		_, interest := scheduleTotals(r.Schedule)
+
+		text, _, _ := runArgs(t, c.args+" --explain")
+		if !strings.Contains(text, "\nInterest = "+interest.String()+" (sum over ") {
+			t.Errorf("%s: the interest column sums to %s:\n%s", c.args, interest, text)
+		}
+
+		rounding := r.Overpayment.Sub(interest).Sub(moneyOf(calculateDrawInterest()))
+		if c.exact && rounding != 0 || rounding <= -100 || rounding >= r.Schedule[0].Payment {
+			t.Errorf("%s: the overpayment %s is %s off the interest", c.args, r.Overpayment, rounding)
+		}
+	}
+}
+
+// TestValidateSum reconciles the diff payments printed with the principal
+// plus overpayment, and reports by how much they differ when they don't.
+func TestValidateSum(t *testing.T) {
+	for _, args := range []string{
+		"--principal=1000000 --periods=10 --interest=10",
+		"--principal=1000 --periods=3 --interest=10",
+		"--principal=999 --periods=13 --interest=17",
+		"--principal=10 --periods=3 --interest=1",
+	} {
+		out, _, _ := runArgs(t, "--type=diff --validate-sum "+args)
+
+		var paid, stated Money
+		last := out[strings.LastIndex(out[:len(out)-1], "\n")+1:]
+		if _, err := fmt.Sscanf(last, "Sum of monthly payments = %v, principal + overpayment = %v", &paid, &stated); err != nil || paid != stated {
+			t.Errorf("%s: %v\n%s", args, err, out)
+		}
+	}
+
+	parseFlags(t, "--type=diff --principal=1000 --periods=3 --interest=10")
+	payments := calculateDiffPayments()
+	rows := diffSchedule(payments)
+
+	stdout = io.Discard
+	defer func() { stdout = os.Stdout }()
+
+	overpayment := diffOverpayment(payments)
+	if err := reconcileSchedule(rows, overpayment.Add(2)); err == nil || err.Error() != "Monthly payments differ from the total by -0.02" {
+		t.Errorf("a discrepancy of 2 cents: %v", err)
+	}
+	if err := reconcileSchedule(rows, overpayment.Sub(1)); err != nil {
+		t.Errorf("within a cent: %v", err)
+	}
+}
+
+// TestSkipMonths compares a loan with two skipped payments with the same
+// loan paid every month: their interest is added to the balance, so it
+// takes longer and costs more.
+func TestSkipMonths(t *testing.T) {
+	result := func(args string) Result {
+		out, _, _ := runArgs(t, "--type=annuity --principal=100000 --periods=120 --interest=6 --format=json"+args)
+
+		var r Result
+		if err := json.Unmarshal([]byte(out), &r); err != nil {
+			t.Fatalf("%s: %v in %s", args, err, out)
+		}
+
+		return r
+	}
+
+	paid, skipped := result(""), result(" --skip-months=3,4")
+
+	if len(paid.Schedule) != 120 || len(skipped.Schedule) != 124 || skipped.Overpayment <= paid.Overpayment {
+		t.Errorf("%d months overpaying %s, skipping %d months overpaying %s",
+			len(paid.Schedule), paid.Overpayment, len(skipped.Schedule), skipped.Overpayment)
+	}
+
+	for _, r := range skipped.Schedule[2:4] {
+		if before := skipped.Schedule[r.Month-2].Balance; r.Payment != 0 || r.Balance != before.Add(r.InterestPortion) {
+			t.Errorf("skipped month %d: %+v after a balance of %s", r.Month, r, before)
+		}
+	}
+
+	out, _, _ := runArgs(t, "--type=annuity --principal=100000 --periods=120 --interest=6 --skip-months=3,4")
+	if !strings.Contains(out, "Skipping 2 payments, it will take 10 years and 4 months to repay this loan\n") {
+		t.Errorf("%q", out)
+	}
+}
+
+func TestSkipMonthsChecked(t *testing.T) {
+	out, _, _ := runArgs(t, "--type=annuity --principal=10000 --periods=60 --interest=10 --skip-months=3")
+	if !strings.Contains(out, "Skipping 1 payment, it will take 5 years and 2 months to repay this loan\n") {
+		t.Errorf("%q", out)
+	}
+
+	// the term is solved again with the payment skipped, and the final
+	// payment is the one the schedule ends on
+	want := "It will take 5 years and 7 months to repay this loan!\n" +
+		"Final payment will be 128.17 instead of 200\n" +
+		"Skipping 1 payment, it will take 5 years and 7 months to repay this loan\n"
+	if out, _, _ := runArgs(t, "--type=annuity --principal=10000 --payment=200 --interest=10 --skip-months=3"); !strings.HasPrefix(out, want) {
+		t.Errorf("%q", out)
+	}
+
+	for args, want := range map[string]string{
+		"--type=annuity --principal=10000 --periods=60 --interest=10 --skip-months=3,61": "out-of-range [skip-months]",
+		"--type=diff --principal=10000 --periods=60 --interest=10 --skip-months=3":       "conflicting [skip-months type]",
+	} {
+		if code, fields := jsonError(t, args); fmt.Sprint(code, " ", fields) != want {
+			t.Errorf("%s: %s %v, want %s", args, code, fields, want)
+		}
+	}
+}
+
+// TestDiffSchedule checks that the principal portions of the diff schedule
+// sum to the principal, each but the last, which repays what's left,
+// within a cent of an even share, and that its payments split into the
+// two portions.
+func TestDiffSchedule(t *testing.T) {
+	for _, args := range []string{
+		"--principal=1000000 --periods=10 --interest=10",
+		"--principal=1000 --periods=7 --interest=12",
+		"--principal=999.99 --periods=13 --interest=17",
+		"--principal=500000 --periods=360 --interest=3",
+	} {
+		parseFlags(t, "--type=diff "+args)
+		rows := diffSchedule(calculateDiffPayments())
+
+		share := moneyOf(principal / float64(periods))
+
+		var repaid Money
+		for _, r := range rows {
+			if r.Payment != r.InterestPortion.Add(r.PrincipalPortion) {
+				t.Errorf("%s: month %d pays %s of %s interest and %s principal", args, r.Month, r.Payment, r.InterestPortion, r.PrincipalPortion)
+			}
+			if d := r.PrincipalPortion.Sub(share); r.Month < periods && (d > 1 || d < -1) {
+				t.Errorf("%s: month %d repays %s, not %s", args, r.Month, r.PrincipalPortion, share)
+			}
+			repaid = repaid.Add(r.PrincipalPortion)
+		}
+
+		if repaid != moneyOf(principal) || rows[len(rows)-1].Balance != 0 {
+			t.Errorf("%s: repays %s of %s, leaving %s", args, repaid, moneyOf(principal), rows[len(rows)-1].Balance)
+		}
+	}
+}
+
+// TestFavor checks both rounding directions of the payment: for the lender
+// it's rounded up and the final payment is smaller, for the borrower down
+// and the final payment larger, both clearing the balance to zero.
+func TestFavor(t *testing.T) {
+	for _, loan := range []string{
+		"--principal=1000000 --periods=60 --interest=10",
+		"--principal=250000 --periods=300 --interest=5.25",
+		"--principal=12345 --periods=7 --interest=3",
+		"--principal=1000 --periods=12 --interest=0",
+	} {
+		parseFlags(t, "--type=annuity --round=none "+loan)
+		exact := getAmortizer().annuityPayment()
+
+		for _, favor := range []string{"lender", "borrower"} {
+			out, _, _ := runArgs(t, "--type=annuity --format=json --favor="+favor+" "+loan)
+
+			var r Result
+			if err := json.Unmarshal([]byte(out), &r); err != nil {
+				t.Fatalf("%s: %v in %s", loan, err, out)
+			}
+
+			last := r.Schedule[len(r.Schedule)-1]
+			rounded := r.Payment.Float64() > exact && last.Payment < r.Payment
+			if favor == "borrower" {
+				rounded = r.Payment.Float64() < exact && last.Payment > r.Payment
+			}
+			if !rounded || len(r.Schedule) != periods || last.Balance != 0 {
+				t.Errorf("%s --favor=%s: pays %s of %g, the final %s leaving %s after %d months",
+					loan, favor, r.Payment, exact, last.Payment, last.Balance, len(r.Schedule))
+			}
+		}
+	}
+}
+
+// TestDiffEvenSplit checks that a principal dividing evenly by the periods
+// is repaid in equal whole portions, summing to it exactly, while the
+// interest still declines.
+func TestDiffEvenSplit(t *testing.T) {
+	for _, args := range []string{
+		"--principal=1000000 --periods=10 --interest=10",
+		"--principal=1200 --periods=12 --interest=7",
+		"--principal=36000000 --periods=360 --interest=3.3",
+		"--principal=6000 --periods=60 --interest=1",
+	} {
+		parseFlags(t, "--type=diff "+args)
+		rows := diffSchedule(calculateDiffPayments())
+		share := moneyOf(principal) / Money(periods)
+
+		var repaid Money
+		for k, r := range rows {
+			if r.PrincipalPortion != share || k > 0 && r.InterestPortion > rows[k-1].InterestPortion {
+				t.Errorf("%s: month %d repays %s of %s with %s interest", args, r.Month, r.PrincipalPortion, share, r.InterestPortion)
+			}
+			repaid = repaid.Add(r.PrincipalPortion)
+		}
+
+		if repaid != moneyOf(principal) || rows[0].InterestPortion <= rows[len(rows)-1].InterestPortion {
+			t.Errorf("%s: repays %s", args, repaid)
+		}
+	}
+}
+
+// TestResidual amortizes a loan down to a residual it leaves unpaid: the
+// schedule ends at the residual, and the overpayment counts only the
+// principal repaid.
+func TestResidual(t *testing.T) {
+	for _, c := range []struct {
+		loan     string
+		residual Money
+	}{
+		{"--principal=10000 --periods=12 --interest=6", moneyOf(4000)},
+		{"--principal=250000 --periods=360 --interest=4.5", moneyOf(50000)},
+		{"--principal=10000 --periods=12 --interest=0", moneyOf(4000)},
+	} {
+		loan := fmt.Sprintf("%s --residual=%s", c.loan, c.residual)
+		out, _, _ := runArgs(t, "--type=annuity --format=json "+loan)
+
+		var r Result
+		if err := json.Unmarshal([]byte(out), &r); err != nil || len(r.Schedule) != r.Periods {
+			t.Fatalf("%s: %v in %s", loan, err, out)
+		}
+
+		last := r.Schedule[len(r.Schedule)-1]
+		if last.Balance != c.residual || last.CumulativePrincipal != r.Principal.Sub(c.residual) {
+			t.Errorf("%s: ends at %s having repaid %s", loan, last.Balance, last.CumulativePrincipal)
+		}
+
+		if want := r.Payment.Mul(float64(r.Periods)).Sub(r.Principal.Sub(c.residual)); r.Overpayment != want {
+			t.Errorf("%s: overpayment %s, want %s", loan, r.Overpayment, want)
+		}
+	}
+}
+
+// TestDiffFixedInterest charges 1% of the original 12000 every month, 1440
+// in all, against 780 on the declining balance.
+func TestDiffFixedInterest(t *testing.T) {
+	const loan = "--type=diff --principal=12000 --periods=12 --interest=12"
+
+	fixed, _, _ := runArgs(t, loan+" --diff-fixed-interest")
+	declining, _, _ := runArgs(t, loan)
+
+	if strings.Count(fixed, "payment is 1120\n") != 12 {
+		t.Errorf("the payments vary:\n%s", fixed)
+	}
+	if !strings.HasSuffix(fixed, "\nOverpayment = 1440\nInterest charged on the original principal; on the declining balance the overpayment would be 780, 660 less\n") {
+		t.Errorf("fixed interest:\n%s", fixed)
+	}
+	if !strings.HasSuffix(declining, "\nOverpayment = 780\n") {
+		t.Errorf("declining balance:\n%s", declining)
+	}
+
+	if out, _, _ := runArgs(t, "--type=annuity --principal=12000 --periods=12 --interest=12 --diff-fixed-interest"); out != "Incorrect parameters\n" {
+		t.Errorf("an annuity: %q", out)
+	}
+}
+
+// TestCrossover finds the month principal overtakes interest in a 30-year
+// loan at 6%, checked against the schedule's rows before and at it.
+func TestCrossover(t *testing.T) {
+	const loan = "--type=annuity --principal=300000 --periods=360 --interest=6"
+
+	out, _, _ := runArgs(t, loan+" --crossover")
+	if !strings.HasSuffix(out, "Principal overtakes interest in month 222\n") {
+		t.Errorf("got %q", out)
+	}
+
+	out, _, _ = runArgs(t, loan+" --format=json")
+	var r Result
+	if err := json.Unmarshal([]byte(out), &r); err != nil {
+		t.Fatalf("%v in %s", err, out)
+	}
+	if before, at := r.Schedule[220], r.Schedule[221]; before.PrincipalPortion > before.InterestPortion || at.PrincipalPortion <= at.InterestPortion {
+		t.Errorf("month 221 %+v, month 222 %+v", before, at)
+	}
+
+	// a short diff loan repays more principal than interest from the start
+	out, _, _ = runArgs(t, "--type=diff --principal=12000 --periods=12 --interest=12 --crossover")
+	if !strings.HasSuffix(out, "Principal overtakes interest in month 1\nDiff payments repay the same principal every month, so it exceeds the interest from the start\n") {
+		t.Errorf("diff: %q", out)
+	}
+}
+
+// TestRoundingResidual quantifies the residual of paying 194 instead of
+// 193.33 for 5 years: the 60 full payments come to 46.84 more than the
+// principal and the schedule's interest, which the final payment leaves out.
+func TestRoundingResidual(t *testing.T) {
+	const loan = "--type=annuity --principal=10000 --periods=60 --interest=6"
+
+	out, _, _ := runArgs(t, loan+" --format=json")
+	var r Result
+	if err := json.Unmarshal([]byte(out), &r); err != nil || r.ScheduledOverpayment == nil {
+		t.Fatalf("%v in %.200s", err, out)
+	}
+
+	residual := r.Payment.Mul(60).Sub(r.Principal).Sub(*r.ScheduledOverpayment)
+	final := r.Schedule[len(r.Schedule)-1].Payment
+	if residual.String() != "46.84" || final != r.Payment.Sub(residual) {
+		t.Errorf("a residual of %s, the final payment %s", residual, final)
+	}
+
+	out, _, _ = runArgs(t, loan+" --verbose")
+	if !strings.HasSuffix(out, "You will have overpaid by 46.84 due to rounding; the final payment is reduced to 147.16 accordingly\n") {
+		t.Errorf("verbose:\n%s", out)
+	}
+	if out, _, _ := runArgs(t, loan); strings.Contains(out, "overpaid by") {
+		t.Errorf("without -verbose:\n%s", out)
+	}
+
+	// reducing the final payment counts only what the schedule pays
+	if out, _, _ := runArgs(t, loan+" --reduce-final-payment"); out != "Your annuity payment = 194!\nOverpayment = "+r.ScheduledOverpayment.String()+"\n" {
+		t.Errorf("reduced:\n%s", out)
+	}
+}
+
+// TestCompactSchedule collapses the equal annuity payments into one run
+// before the reduced final payment, while every diff payment differs.
+func TestCompactSchedule(t *testing.T) {
+	compact := func(args string) []string {
+		out, _, _ := runArgs(t, args+" --compact-schedule")
+		return strings.Split(strings.TrimSuffix(out[strings.LastIndex(out, "\n\n")+2:], "\n"), "\n")
+	}
+
+	if got := compact("--type=annuity --principal=200000 --periods=360 --interest=6"); strings.Join(got, "\n") != "Months 1–359: 1200\nMonth 360: 296.94" {
+		t.Errorf("annuity: %q", got)
+	}
+
+	if got := compact("--type=annuity --principal=1000 --periods=12 --interest=0"); strings.Join(got, "\n") != "Months 1–11: 84\nMonth 12: 76" {
+		t.Errorf("without interest: %q", got)
+	}
+
+	if got := compact("--type=diff --principal=100000 --periods=120 --interest=6"); len(got) != 120 || got[119] != "Month 120: 838" {
+		t.Errorf("diff: %d lines, the last %q", len(got), got[len(got)-1])
+	}
+}
+
+// TestScheduleReverse counts down from the final month, which leaves
+// nothing to pay, through the same rows as the forward schedule.
+func TestScheduleReverse(t *testing.T) {
+	table := func(args string) []string {
+		out, _, _ := runArgs(t, args)
+		return strings.Split(strings.TrimSuffix(out[strings.LastIndex(out, "\n\n")+2:], "\n"), "\n")
+	}
+
+	for _, loan := range []string{
+		"--type=annuity --principal=1000 --periods=3 --interest=12",
+		"--type=annuity --principal=200000 --periods=360 --interest=6",
+	} {
+		reversed, forward := table(loan+" --schedule-reverse"), table(loan+" --schedule")
+		if len(reversed) != len(forward) || strings.Fields(reversed[0])[4] != "Left" {
+			t.Fatalf("%s:\n%s", loan, strings.Join(reversed, "\n"))
+		}
+
+		if first := strings.Fields(reversed[1]); first[0] != strings.Fields(forward[len(forward)-1])[0] || first[4] != "0" {
+			t.Errorf("%s: the countdown starts at %q", loan, reversed[1])
+		}
+
+		for k := 1; k < len(forward); k++ {
+			if strings.Join(strings.Fields(reversed[k]), " ") != strings.Join(strings.Fields(forward[len(forward)-k]), " ") {
+				t.Errorf("%s: row %q, forward %q", loan, reversed[k], forward[len(forward)-k])
+				break
+			}
+		}
+	}
+}
+
+// TestExplainFinalPayment solves a term the payment doesn't divide, whose
+// final payment the schedule reduces by 11179.54: the explanation names
+// that gap rather than putting it down to rounding.
+func TestExplainFinalPayment(t *testing.T) {
+	out, _, _ := runArgs(t, "--type=annuity --principal=500000 --payment=23000 --interest=7.8 --explain")
+
+	want := "\nInterest = 40820.46 (sum over 24 months)\nTotal paid = 540820.46\n" +
+		"Overpayment counts 11179.54 more than the schedule pays, taking its final payment of 11820.46 in full\n"
+	if !strings.HasSuffix(out, want) || strings.Contains(out, "due to rounding") {
+		t.Errorf("got\n%s", out)
+	}
+
+	// diff payments are all paid as scheduled
+	out, _, _ = runArgs(t, "--type=diff --principal=10000 --periods=7 --interest=6 --explain")
+	if strings.Contains(out, "Overpayment counts") {
+		t.Errorf("diff:\n%s", out)
+	}
+}
+
+// TestCumulativeColumns checks that the last row of the JSON schedule has
+// repaid the principal and that its interest is the scheduled overpayment,
+// which differs from the counted one by the rounding residual alone, or
+// the rounding up of a diff overpayment.
+func TestCumulativeColumns(t *testing.T) {
+	for _, args := range []string{
+		"--type=annuity --principal=1000000 --periods=60 --interest=10",
+		"--type=annuity --principal=1000000 --periods=60 --interest=10 --reduce-final-payment",
+		"--type=annuity --principal=250000 --periods=300 --interest=5.25 --favor=borrower",
+		"--type=annuity --principal=1000 --periods=12 --interest=0",
+		"--type=diff --principal=500000 --periods=3 --interest=7.8",
+		"--type=diff --principal=1000000 --periods=120 --interest=6",
+	} {
+		out, _, _ := runArgs(t, args+" --format=json")
+
+		var r Result
+		if err := json.Unmarshal([]byte(out), &r); err != nil || len(r.Schedule) == 0 || r.ScheduledOverpayment == nil {
+			t.Fatalf("%s: %v in %s", args, err, out)
+		}
+
+		last := r.Schedule[len(r.Schedule)-1]
+		if last.CumulativePrincipal != r.Principal {
+			t.Errorf("%s: cumulative principal %s, principal %s", args, last.CumulativePrincipal, r.Principal)
+		}
+		if last.CumulativeInterest != *r.ScheduledOverpayment {
+			t.Errorf("%s: cumulative interest %s, scheduled overpayment %s", args, last.CumulativeInterest, *r.ScheduledOverpayment)
+		}
+
+		if gap := r.Overpayment.Sub(*r.ScheduledOverpayment); gap != 0 {
+			text, _, _ := runArgs(t, args+" --verbose")
+			residual := strings.Contains(text, "You will have overpaid by "+gap.String()+" due to rounding")
+			diffCeil := strings.HasPrefix(args, "--type=diff") && gap > 0 && gap < 100
+			if !residual && !diffCeil {
+				t.Errorf("%s: overpayment %s is %s over the schedule, unexplained:\n%s", args, r.Overpayment, gap, text)
+			}
+		}
+	}
+}
+
+func TestCumulativeCSV(t *testing.T) {
+	out, _, _ := runArgs(t, "--type=annuity --principal=1000 --periods=3 --interest=12 --format=csv")
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 4 || !strings.HasSuffix(lines[0], ",cumulative_interest,cumulative_principal") ||
+		!strings.HasSuffix(lines[3], ",20.04,1000") {
+		t.Errorf("CSV schedule:\n%s", out)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// selfCheckTolerance is the largest difference, in cents, the self-check
+// accepts between two ways of totalling the same payments.
+const selfCheckTolerance = 1
+
+// unroundedTolerance widens selfCheckTolerance when the payment is kept
+// unrounded: the schedule pays it to the nearest cent, and each half cent
+// it falls short by or pays over accrues interest until the final payment.
+func unroundedTolerance(rows int) Money {
+	i := getInterestRate()
+	if i == 0 {
+		return selfCheckTolerance + Money(rows+1)/2
+	}
+
+	return selfCheckTolerance + Money(math.Ceil(compoundGrowth(i, rows)/i/2))
+}
+
+// checkAnnuitySchedule asserts for -self-check that the schedule pays the
+// payment every month but the last, which may be smaller, and that the
+// principal plus the reported overpayment reconciles with what it counts
+// as paid: the schedule's sum when the overpayment is taken from it, as
+// with -reduce-final-payment, and otherwise every one of the periods paid
+// in full.
+func checkAnnuitySchedule(rows []ScheduleRow, overpayment Money) error {
+	if len(rows) > periods || len(rows) == 0 {
+		return fmt.Errorf(msg("self-check-rows"), len(rows), periods)
+	}
+
+	amount := moneyOf(payment)
+
+	var sum, final Money
+	for k, r := range rows {
+		paid := r.Payment
+		if k == 0 {
+			paid = paid.Sub(stubPaid())
+		}
+
+		if k < len(rows)-1 && paid != amount {
+			return fmt.Errorf(msg("self-check-payment"), r.Month, paid, amount)
+		}
+
+		sum, final = sum.Add(paid), paid
+	}
+
+	if balance := rows[len(rows)-1].Balance; balance != moneyOf(residual) {
+		return fmt.Errorf(msg("self-check-balance"), balance)
+	}
+
+	if err := withinTolerance(sum, amount.Mul(float64(len(rows)-1)).Add(final), selfCheckTolerance); err != nil {
+		return err
+	}
+
+	tolerance := Money(selfCheckTolerance)
+	if displayRounding || roundPolicy == "none" {
+		tolerance = unroundedTolerance(len(rows))
+	}
+
+	counted := sum
+	if !overpaymentFromSchedule() {
+		counted = amount.Mul(float64(periods))
+	}
+
+	stated := loanPrincipal().Sub(moneyOf(residual)).Add(overpayment).
+		Sub(moneyOf(calculateDrawInterest())).Sub(stubPaid())
+	if err := withinTolerance(counted, stated, tolerance); err != nil {
+		return err
+	}
+
+	if counted != sum {
+		fmt.Fprintf(stdout, msg("self-check-passed-full"), len(rows), sum, periods, counted)
+		return nil
+	}
+
+	fmt.Fprintf(stdout, msg("self-check-passed"), len(rows), sum)
+
+	return nil
+}
+
+func withinTolerance(got, want, tolerance Money) error {
+	if diff := got.Sub(want); diff > tolerance || diff < -tolerance {
+		return fmt.Errorf(msg("self-check-total"), got, want)
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// TestSelfCheckGeneratedLoans runs the self-check over loans of many
+// sizes, rates, terms and rounding policies. With -reduce-final-payment the
+// overpayment is what the schedule pays, and otherwise every period paid
+// in full; either way every one must pass.
+func TestSelfCheckGeneratedLoans(t *testing.T) {
+	saved := stdout
+	defer func() { stdout = saved }()
+	stdout = io.Discard
+
+	checked := 0
+	for _, p := range []string{"1000", "12345.67", "1000000", "25000000"} {
+		for _, rate := range []string{"0", "0.5", "3.75", "10", "24"} {
+			for _, n := range []int{1, 2, 12, 59, 360} {
+				for _, rounding := range []string{"--favor=lender", "--favor=borrower", "--round=nearest", "--round=half-even", "--round=none", "--round-display-only"} {
+					args := fmt.Sprintf("--type=annuity --principal=%s --interest=%s --periods=%d %s", p, rate, n, rounding)
+					parseFlags(t, args)
+					payment = getAmortizer().annuityPayment()
+
+					rows := annuitySchedule()
+
+					reduceFinal = true
+					if err := checkAnnuitySchedule(rows, calculateOverpayment()); err != nil {
+						t.Errorf("%s --reduce-final-payment: %v", args, err)
+					}
+
+					reduceFinal = false
+					if err := checkAnnuitySchedule(rows, calculateOverpayment()); err != nil {
+						t.Errorf("%s: %v", args, err)
+					}
+
+					checked++
+				}
+			}
+		}
+	}
+
+	t.Logf("%d loans checked", checked)
+}
+
+func TestSelfCheckReportsFinalPayment(t *testing.T) {
+	out, _, _ := runArgs(t, "--type=annuity --principal=1000000 --periods=60 --interest=10 --self-check")
+	if !strings.Contains(out, "Self-check passed: 60 payments sum to 1274806.02, and the 60 periods in full to 1274880 as the overpayment counts them\n") {
+		t.Errorf("the full final payment isn't reconciled:\n%s", out)
+	}
+
+	out, _, _ = runArgs(t, "--type=annuity --principal=1000000 --periods=60 --interest=10 --self-check --reduce-final-payment")
+	if !strings.Contains(out, "Overpayment = 274806.02\n") || !strings.Contains(out, "Self-check passed: 60 payments sum to 1274806.02") {
+		t.Errorf("the reduced final payment doesn't reconcile:\n%s", out)
+	}
+
+	// an overpayment off the basis it's counted on fails either way
+	for _, args := range []string{"", " --reduce-final-payment"} {
+		parseFlags(t, "--type=annuity --principal=1000000 --periods=60 --interest=10"+args)
+		payment = getAmortizer().annuityPayment()
+
+		err := checkAnnuitySchedule(annuitySchedule(), calculateOverpayment().Add(100))
+		if err == nil || !strings.HasPrefix(err.Error(), "Self-check failed: the payments sum to") {
+			t.Errorf("%q: %v", args, err)
+		}
+	}
+}
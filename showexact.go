@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// exactPayment and exactPrincipal keep the figures before their rounding,
+// for -show-exact.
+var exactPayment, exactPrincipal float64
+
+// keepExactFigures recomputes whichever of the payment and principal the
+// action solved for without rounding it.
+func keepExactFigures(action CalcType) {
+	exactPayment, exactPrincipal = payment, principal
+	if !showExact {
+		return
+	}
+
+	switch action {
+	case CalcPrincipal:
+		exactPrincipal = unrounded(getAmortizer().annuityPrincipal)
+	case CalcPayment, CalcMaxPrincipal, CalcMaxPeriod:
+		exactPayment = unrounded(getAmortizer().annuityPayment)
+	}
+}
+
+// exactOverpayment is the overpayment of the unrounded figures.
+func exactOverpayment() float64 {
+	savedPayment, savedPrincipal := payment, principal
+	defer func() { payment, principal = savedPayment, savedPrincipal }()
+
+	payment, principal = exactPayment, exactPrincipal
+
+	return unrounded(func() float64 { return calculateOverpayment().Float64() })
+}
+
+func unrounded(f func() float64) float64 {
+	saved := displayRounding
+	defer func() { displayRounding = saved }()
+
+	displayRounding = true
+
+	return f()
+}
+
+// withExact appends the unrounded value to a rounded figure under
+// -show-exact.
+func withExact(rounded Money, exact float64) string {
+	if !showExact {
+		return rounded.String()
+	}
+
+	if !centsMode {
+		return fmt.Sprintf("%s (%s)", rounded, strconv.FormatFloat(exact, 'f', 2, 64))
+	}
+
+	// in cents, only a fraction of a cent has decimals
+	cents := strconv.FormatFloat(exact*100, 'f', 2, 64)
+
+	return fmt.Sprintf("%s (%s)", rounded, strings.TrimSuffix(strings.TrimRight(cents, "0"), "."))
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+// TestShowExact checks that each rounded figure is followed by its exact
+// value, within a unit of it, and that the exact overpayment follows from
+// the exact payment or principal.
+func TestShowExact(t *testing.T) {
+	for _, c := range []struct {
+		args, solved string
+	}{
+		{"--principal=1000000 --periods=120 --interest=1", "Your annuity payment"},
+		{"--payment=8761 --periods=120 --interest=1", "Your loan principal"},
+	} {
+		out, _, _ := runArgs(t, "--type=annuity --show-exact "+c.args)
+		lines := strings.Split(out, "\n")
+
+		var figure, exactFigure, over, exactOver float64
+		if _, err := fmt.Sscanf(strings.TrimPrefix(lines[0], c.solved), " = %g (%g)!", &figure, &exactFigure); err != nil {
+			t.Fatalf("%s: %v in %q", c.args, err, out)
+		}
+		if _, err := fmt.Sscanf(lines[1], "Overpayment = %g (%g)", &over, &exactOver); err != nil {
+			t.Fatalf("%s: %v in %q", c.args, err, out)
+		}
+
+		// a payment rounded up by under a unit overpays under a unit a month
+		if math.Abs(figure-exactFigure) >= 1 || math.Abs(over-exactOver) >= 120 {
+			t.Errorf("%s: %g against %g, overpaying %g against %g", c.args, figure, exactFigure, over, exactOver)
+		}
+
+		plain, _, _ := runArgs(t, "--type=annuity "+c.args)
+		if want := fmt.Sprintf("%s = %.0f!\nOverpayment = %.0f\n", c.solved, figure, over); plain != want {
+			t.Errorf("%s: without -show-exact %q, want %q", c.args, plain, want)
+		}
+	}
+
+	out, _, _ := runArgs(t, "--type=annuity --principal=1000000 --periods=120 --interest=1 --show-exact")
+	var exactPayment, exactOver float64
+	fmt.Sscanf(out, "Your annuity payment = 8761 (%g)!\nOverpayment = 51320 (%g)", &exactPayment, &exactOver)
+	// the exact payment is shown to the cent, so 120 of them to within 0.6
+	if want := exactPayment*120 - 1000000; math.Abs(exactOver-want) > 0.6 {
+		t.Errorf("exact overpayment %g, want %g", exactOver, want)
+	}
+}
+
+// TestShowExactCents prints the exact figures in cents too, with decimals
+// only for a fraction of a cent.
+func TestShowExactCents(t *testing.T) {
+	out, _, _ := runArgs(t, "--type=annuity --cents --principal=100000000 --periods=60 --interest=10 --show-exact")
+	if want := "Your annuity payment = 2124705 (2124704.47)!\nOverpayment = 27482300 (27482268)\n"; out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+}
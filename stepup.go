@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// stepFactor is the -step-up multiplier of the payment in month m.
+func stepFactor(m int) float64 {
+	f := 1.0
+
+	for _, s := range stepUps {
+		if s.month <= m {
+			f = s.value
+		}
+	}
+
+	return f
+}
+
+// steppedAmount scales the base payment by the step of month m, rounding
+// a scaled one up like the base.
+func steppedAmount(base Money, m int) Money {
+	f := stepFactor(m)
+	if f == 1 {
+		return base
+	}
+
+	return base.Mul(f).Ceil()
+}
+
+func validStepUps() bool {
+	for _, s := range stepUps {
+		if s.month > periods || s.value <= 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// calculateStepPayment solves for the base payment whose stepped payments
+// clear the balance by the end of the term.
+func calculateStepPayment() (float64, error) {
+	i := getInterestRate()
+
+	smallest := 1.0
+	for _, s := range stepUps {
+		smallest = min(smallest, s.value)
+	}
+
+	// a single payment of the compounded principal clears it at any step
+	hi := principal * math.Pow(1+i, float64(periods)) / smallest
+
+	// the balance grows with the principal, so a residual of it within
+	// the absolute tolerance may be out of reach of a large loan
+	base, err := bisect(0, hi, 0.01, func(base float64) float64 {
+		balance := principal
+		for m := 1; m <= periods; m++ {
+			balance = balance*(1+i) - base*stepFactor(m)
+		}
+		return -balance
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return roundPayment(base), nil
+}
+
+func displaySteps() {
+	base := moneyOf(payment)
+
+	for _, s := range stepUps {
+		fmt.Fprintf(stdout, msg("step-up"), s.month, steppedAmount(base, s.month))
+	}
+}
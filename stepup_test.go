@@ -0,0 +1,36 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestStepUpAmortizes checks that a two-step schedule clears the balance
+// in its last month, which pays no more than the stepped payment.
+func TestStepUpAmortizes(t *testing.T) {
+	for _, args := range []string{
+		"--principal=100000 --periods=24 --interest=6 --step-up=13:1.5",
+		"--principal=250000 --periods=360 --interest=4.5 --step-up=61:1.2,121:1.4",
+		"--principal=1e11 --periods=360 --interest=6 --step-up=13:1.1",
+	} {
+		out, errOut, _ := runArgs(t, "--type=annuity --format=json "+args)
+
+		var r Result
+		if err := json.Unmarshal([]byte(out), &r); err != nil || len(r.Schedule) != r.Periods {
+			t.Fatalf("%s: %v in %s%s", args, err, out, errOut)
+		}
+
+		stepped := r.Schedule[len(r.Schedule)-2].Payment
+		if last := r.Schedule[len(r.Schedule)-1]; last.Balance != 0 || last.Payment > stepped {
+			t.Errorf("%s: last month pays %s of a stepped %s, leaving %s", args, last.Payment, stepped, last.Balance)
+		}
+		if r.Schedule[0].Payment == stepped {
+			t.Errorf("%s: the payment never steps up from %s", args, stepped)
+		}
+	}
+
+	if out, _, _ := runArgs(t, "--type=annuity --principal=1e11 --periods=360 --interest=6 --step-up=13:1.1"); strings.Contains(out, "converge") {
+		t.Errorf("a large principal doesn't converge: %s", out)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import "fmt"
+
+// doStressTest qualifies the borrower for the principal whose payment at
+// -stress-rate is within -max-payment, and prints what the loan then costs
+// at the contract -interest.
+func doStressTest() error {
+	if !isProvided("periods", "interest", "max-payment") {
+		return underSpecified("periods", "interest", "max-payment")
+	}
+
+	if periods <= 0 {
+		return outOfRange("periods")
+	}
+
+	if maxPayment <= 0 {
+		return outOfRange("max-payment")
+	}
+
+	if stressRate < interest {
+		return outOfRange("stress-rate")
+	}
+
+	rate := interest
+	payment = maxPayment
+
+	unstressed := getAmortizer().annuityPrincipal()
+
+	interest = stressRate
+	principal = getAmortizer().annuityPrincipal()
+	interest = rate
+
+	payment = getAmortizer().annuityPayment()
+
+	fmt.Fprintf(stdout, msg("stress-principal"), stressRate, moneyOf(principal), moneyOf(maxPayment))
+	fmt.Fprintf(stdout, msg("stress-unstressed"), interest, moneyOf(unstressed))
+	fmt.Fprintf(stdout, msg("stress-payment"), moneyOf(payment), interest)
+
+	return nil
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestStressRate qualifies a payment of 2000 at 7% instead of the contract
+// rate of 5%, which reduces the principal from that of the principal mode
+// at 5% to that at 7%.
+func TestStressRate(t *testing.T) {
+	out, _, _ := runArgs(t, "--periods=360 --interest=5 --stress-rate=7 --max-payment=2000")
+	want := "At the stress rate of 7% you qualify for a principal of 300615 with a payment of 2000\n" +
+		"At the contract rate of 5% it would be 372563\n" +
+		"Your annuity payment = 1614 at the contract rate of 5%!\n"
+	if out != want {
+		t.Errorf("got\n%s\nwant\n%s", out, want)
+	}
+
+	for args, want := range map[string]string{
+		"--payment=2000 --periods=360 --interest=7":     "Your loan principal = 300615!\n",
+		"--payment=2000 --periods=360 --interest=5":     "Your loan principal = 372563!\n",
+		"--principal=300615 --periods=360 --interest=5": "Your annuity payment = 1614!\n",
+	} {
+		if out, _, _ := runArgs(t, "--type=annuity "+args); !strings.HasPrefix(out, want) {
+			t.Errorf("%s: %q, want %q", args, out, want)
+		}
+	}
+
+	if out, _, _ := runArgs(t, "--periods=360 --interest=5 --stress-rate=4 --max-payment=2000"); out != "Incorrect parameters\n" {
+		t.Errorf("a stress rate below the contract rate: %q", out)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import "sort"
+
+// coreInputs are the loan values a calculation may solve from.
+var coreInputs = []string{"payment", "principal", "periods", "years", "interest", "max-overpayment"}
+
+// consumedInputs are the inputs each calculation takes, besides the values
+// an annuity's -solve needs; -strict rejects any other of strictInputs.
+var consumedInputs = map[CalcType][]string{
+	CalcAnnual: {"skip-months", "recast", "step-up", "extra-monthly", "residual", "disbursements", "payoff-at",
+		"shorten-by-years", "round-payment-up-to", "interest-cap-percent", "monthly-tax", "monthly-insurance", "original-periods"},
+	CalcDiff: {"principal", "periods", "interest", "max-overpayment", "diff-fixed-interest",
+		"monthly-tax", "monthly-insurance", "original-periods"},
+	CalcOffers:              {"principal", "offers"},
+	CalcConsolidation:       {"consolidate"},
+	CalcInterestOnly:        {"principal", "periods", "interest", "interest-only", "interest-only-months"},
+	CalcRefinance:           {"principal", "periods", "interest", "new-interest", "closing-costs"},
+	CalcDownPayment:         {"price", "periods", "interest", "target-payment"},
+	CalcRateSweep:           {"principal", "rate-sweep", "terms"},
+	CalcFrequencyComparison: {"principal", "periods", "interest", "compare-frequency"},
+	CalcStressTest:          {"periods", "interest", "stress-rate", "max-payment"},
+	CalcRoundingComparison:  {"principal", "periods", "interest", "compare-rounding"},
+	CalcAffordability:       {"budget", "periods", "interest", "monthly-tax", "monthly-insurance", "down-payment"},
+}
+
+// strictInputs are the inputs some calculation takes, the loan values
+// first: those a calculation doesn't take are the ones -strict rejects.
+func strictInputs() []string {
+	var seen = make(map[string]bool)
+	for _, name := range coreInputs {
+		seen[name] = true
+	}
+
+	var rest []string
+	for _, names := range consumedInputs {
+		for _, name := range names {
+			if !seen[name] {
+				seen[name] = true
+				rest = append(rest, name)
+			}
+		}
+	}
+	sort.Strings(rest)
+
+	return append(append([]string(nil), coreInputs...), rest...)
+}
+
+// checkStrict requires an explicit -solve for annuities and rejects any
+// of strictInputs the calculation would ignore.
+func checkStrict(action CalcType) error {
+	var used = make(map[string]bool)
+	for _, name := range consumedInputs[action] {
+		used[name] = true
+	}
+
+	if action == CalcAnnual {
+		target, ok := solveTargets[solve]
+		if !ok {
+			return underSpecified("solve")
+		}
+		for _, name := range target.needs {
+			used[name] = true
+		}
+	}
+	used["years"] = used["periods"]
+
+	var unused []string
+	for _, name := range strictInputs() {
+		if provided[name] && !used[name] {
+			unused = append(unused, name)
+		}
+	}
+
+	if len(unused) > 0 {
+		return paramError{"unused", unused}
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestStrict(t *testing.T) {
+	for _, c := range []struct {
+		args string
+		want string
+	}{
+		{"--solve=payment --principal=1000 --periods=12 --interest=5", "Your annuity payment = 86!"},
+		{"--solve=period --principal=1000 --payment=100 --interest=5", "It will take 11 months"},
+		{"--solve=payment --principal=1000 --years=1 --interest=5", "Your annuity payment = 86!"},
+		{"--principal=1000 --periods=12 --interest=5", "Incorrect parameters\n"},
+		{"--solve=payment --principal=1000 --periods=12 --interest=5 --payment=5",
+			"Incorrect parameters: -payment not used by the calculation\n"},
+		{"--solve=principal --payment=100 --periods=12 --interest=5 --principal=1000 --max-overpayment=9",
+			"Incorrect parameters: -principal, -max-overpayment not used by the calculation\n"},
+	} {
+		out, _, _ := runArgs(t, "--strict --type=annuity "+c.args)
+		if !strings.HasPrefix(out, c.want) {
+			t.Errorf("%s: %q, want %q", c.args, out, c.want)
+		}
+	}
+
+	// every calculation rejects the inputs it doesn't take
+	for _, c := range []struct {
+		args   string
+		unused string
+	}{
+		{"--type=diff --principal=1000 --periods=2 --interest=5 --payment=5", "[payment]"},
+		{"--type=diff --principal=1000 --periods=12 --interest=5 --recast=4:100 --step-up=6:1.1 --extra-monthly=10",
+			"[extra-monthly recast step-up]"},
+		{"--interest-only --principal=1000 --periods=12 --interest=5 --payment=100", "[payment]"},
+		{"--stress-rate=8 --max-payment=500 --periods=120 --interest=5 --payment=100", "[payment]"},
+		{"--budget=2000 --periods=360 --interest=6 --payment=100 --principal=5000", "[payment principal]"},
+		{"--rate-sweep=5,6 --terms=12 --principal=1000 --interest=5", "[interest]"},
+		{"--offers=offers.csv --principal=1000 --consolidate=loans.csv", "[consolidate]"},
+	} {
+		if code, fields := jsonError(t, "--strict "+c.args); code != "unused" || fmt.Sprint(fields) != c.unused {
+			t.Errorf("%s: %s %v, want unused %s", c.args, code, fields, c.unused)
+		}
+	}
+
+	// and takes those it does
+	for _, args := range []string{
+		"--type=diff --principal=1000 --periods=12 --interest=5 --diff-fixed-interest --monthly-tax=10",
+		"--type=annuity --solve=payment --principal=1000 --periods=12 --interest=5 --skip-months=3 --extra-monthly=10",
+		"--budget=2000 --periods=360 --interest=6 --down-payment=5000 --monthly-tax=100",
+	} {
+		if out, _, _ := runArgs(t, "--strict "+args); strings.HasPrefix(out, "Incorrect parameters") {
+			t.Errorf("%s: %q", args, out)
+		}
+	}
+}
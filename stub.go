@@ -0,0 +1,88 @@
+package main
+
+import "fmt"
+
+// applyStubPeriod works out the interest accrued between -start-date and
+// -first-payment-date beyond the regular month, capitalizing it into the
+// principal if asked to.
+func applyStubPeriod() error {
+	stubInterest = 0
+
+	if firstPaymentDate.IsZero() {
+		return nil
+	}
+
+	if !isProvided("principal") {
+		return underSpecified("principal")
+	}
+
+	if stubMode != "separate" && stubMode != "capitalize" {
+		return outOfRange("stub-interest")
+	}
+
+	regular := startDate.AddDate(0, 1, 0)
+	if firstPaymentDate.Before(startDate.Time) {
+		return outOfRange("first-payment-date")
+	}
+
+	days := firstPaymentDate.Sub(regular).Hours() / 24
+	if days <= 0 {
+		return nil
+	}
+
+	stubInterest = principal * (interest - interestSubsidy) / 100 * days / 365
+	if stubMode == "capitalize" {
+		principal += stubInterest
+	}
+
+	return nil
+}
+
+// stubPaid is the stub interest due with the first payment.
+func stubPaid() Money {
+	if stubMode == "capitalize" {
+		return 0
+	}
+
+	return moneyOf(roundUp(stubInterest))
+}
+
+// loanPrincipal is the principal borrowed, without capitalized stub
+// interest.
+func loanPrincipal() Money {
+	if stubMode == "capitalize" {
+		return moneyOf(principal).Sub(moneyOf(stubInterest))
+	}
+
+	return moneyOf(principal)
+}
+
+// addStubPayment folds the separately paid stub interest into the first
+// row of a schedule.
+func addStubPayment(rows []ScheduleRow) []ScheduleRow {
+	stub := stubPaid()
+	if stub == 0 || len(rows) == 0 {
+		return rows
+	}
+
+	for k := range rows {
+		rows[k].CumulativeInterest = rows[k].CumulativeInterest.Add(stub)
+	}
+
+	rows[0].Payment = rows[0].Payment.Add(stub)
+	rows[0].InterestPortion = rows[0].InterestPortion.Add(stub)
+
+	return rows
+}
+
+func displayStub() {
+	if stubInterest <= 0 {
+		return
+	}
+
+	if stubMode == "capitalize" {
+		fmt.Fprintf(stdout, msg("stub-capitalized"), moneyOf(stubInterest))
+	} else {
+		fmt.Fprintf(stdout, msg("stub-separate"), stubPaid())
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+// TestStubPeriod compares a loan whose first payment is a month late with
+// the same loan paid on time: the 29 days from 1 February to 1 March
+// accrue 100000 * 12% * 29/365 = 953.42 of interest more.
+func TestStubPeriod(t *testing.T) {
+	result := func(args string) Result {
+		out, _, _ := runArgs(t, "--type=annuity --principal=100000 --periods=12 --interest=12 --start-date=2024-01-01 --format=json"+args)
+
+		var r Result
+		if err := json.Unmarshal([]byte(out), &r); err != nil {
+			t.Fatalf("%s: %v in %s", args, err, out)
+		}
+
+		return r
+	}
+
+	onTime := result("")
+	separate := result(" --first-payment-date=2024-03-01")
+	capitalized := result(" --first-payment-date=2024-03-01 --stub-interest=capitalize")
+
+	if separate.Payment != onTime.Payment || separate.Overpayment != onTime.Overpayment.Add(95400) {
+		t.Errorf("paid separately: %s overpaying %s, on time %s overpaying %s", separate.Payment, separate.Overpayment, onTime.Payment, onTime.Overpayment)
+	}
+	if first := separate.Schedule[0]; first.Payment != onTime.Schedule[0].Payment.Add(95400) || first.Balance != onTime.Schedule[0].Balance {
+		t.Errorf("the first payment: %+v, on time %+v", first, onTime.Schedule[0])
+	}
+
+	if capitalized.Principal != 10095342 || capitalized.Payment <= onTime.Payment || capitalized.Overpayment <= separate.Overpayment {
+		t.Errorf("capitalized: %s paying %s overpaying %s", capitalized.Principal, capitalized.Payment, capitalized.Overpayment)
+	}
+
+	for _, args := range []string{" --first-payment-date=2023-12-01", " --first-payment-date=2024-03-01 --stub-interest=later"} {
+		if out, _, _ := runArgs(t, "--type=annuity --principal=100000 --periods=12 --interest=12 --start-date=2024-01-01"+args); out != "Incorrect parameters\n" {
+			t.Errorf("%s: %q", args, out)
+		}
+	}
+
+	for _, c := range []struct{ args, want string }{
+		{"--principal=100000 --first-payment-date=2023-12-01", "out-of-range [first-payment-date]"},
+		{"--principal=100000 --first-payment-date=2024-03-01 --stub-interest=later", "out-of-range [stub-interest]"},
+		{"--payment=9000 --first-payment-date=2024-03-01", "under-specified [principal]"},
+	} {
+		if code, fields := jsonError(t, "--type=annuity --periods=12 --interest=12 --start-date=2024-01-01 "+c.args); fmt.Sprint(code, " ", fields) != c.want {
+			t.Errorf("%s: %s %v, want %s", c.args, code, fields, c.want)
+		}
+	}
+}
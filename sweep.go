@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"text/tabwriter"
+)
+
+// doRateSweep prints the payment for the principal at every -rate-sweep
+// rate and -terms term, one row per rate.
+func doRateSweep() error {
+	if !isProvided("principal", "terms") {
+		return underSpecified("principal", "terms")
+	}
+
+	var cells = make([][]string, len(rateSweep))
+
+	for r, v := range rateSweep {
+		interest = v
+		for _, n := range sweepTerms {
+			periods = n
+			cells[r] = append(cells[r], moneyOf(getAmortizer().annuityPayment()).String())
+		}
+	}
+
+	displaySweep(cells)
+
+	return nil
+}
+
+// displaySweep splits the table into blocks of as many term columns as fit
+// the terminal.
+func displaySweep(cells [][]string) {
+	cell := 0
+	for _, row := range cells {
+		for _, c := range row {
+			cell = max(cell, len(c))
+		}
+	}
+	for _, n := range sweepTerms {
+		cell = max(cell, len(strconv.Itoa(n)))
+	}
+
+	label := len(msg("sweep-rate"))
+	for _, v := range rateSweep {
+		label = max(label, len(strconv.FormatFloat(v, 'f', -1, 64))+1)
+	}
+
+	perBlock := max(1, (terminalWidth()-label)/(cell+2))
+
+	for from := 0; from < len(sweepTerms); from += perBlock {
+		to := min(from+perBlock, len(sweepTerms))
+
+		if from > 0 {
+			fmt.Fprintln(stdout)
+		}
+
+		w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+
+		fmt.Fprint(w, msg("sweep-rate"), "\t")
+		for _, n := range sweepTerms[from:to] {
+			fmt.Fprintf(w, "%d\t", n)
+		}
+		fmt.Fprintln(w)
+
+		for r, v := range rateSweep {
+			fmt.Fprintf(w, "%s%%\t", strconv.FormatFloat(v, 'f', -1, 64))
+			for _, c := range cells[r][from:to] {
+				fmt.Fprintf(w, "%s\t", c)
+			}
+			fmt.Fprintln(w)
+		}
+
+		w.Flush()
+	}
+}
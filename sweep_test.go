@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRateSweep(t *testing.T) {
+	const args = "--principal=10000 --rate-sweep=6,12 --terms=12,24"
+
+	cells := func(out string) [][]string {
+		var rows [][]string
+		for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+			if line != "" {
+				rows = append(rows, strings.Fields(line))
+			}
+		}
+		return rows
+	}
+
+	out, _, _ := runArgs(t, args)
+	want := [][]string{{"Rate", "12", "24"}, {"6%", "861", "444"}, {"12%", "889", "471"}}
+	if got := cells(out); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// a terminal narrower than the table splits it into blocks of terms
+	t.Setenv("COLUMNS", "12")
+	out, _, _ = runArgs(t, args)
+	want = [][]string{{"Rate", "12"}, {"6%", "861"}, {"12%", "889"}, {"Rate", "24"}, {"6%", "444"}, {"12%", "471"}}
+	if got := cells(out); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("at 12 columns got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,27 @@
+// Calls the calculator built with -tags cshared -buildmode=c-shared, for
+// TestCShared.
+#include <stdio.h>
+#include "libloan.h"
+
+int main(void) {
+	double payment, principal;
+	int periods;
+
+	if (AnnuityPayment(1000000, 10, 60, &payment) != 0) {
+		return 1;
+	}
+	if (AnnuityPrincipal(8722, 5.6, 120, &principal) != 0) {
+		return 1;
+	}
+	if (AnnuityPeriods(500000, 23000, 7.8, &periods) != 0) {
+		return 1;
+	}
+
+	printf("payment %g\nprincipal %g\nperiods %d\n", payment, principal, periods);
+
+	// a payment short of the interest is incorrect
+	printf("short payment %d\n", AnnuityPeriods(500000, 1000, 7.8, &periods));
+	printf("negative principal %d\n", AnnuityPayment(-1, 10, 60, &payment));
+
+	return 0;
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"text/tabwriter"
+	"time"
+)
+
+// paymentDate is the date of the k-th payment, counted from 1.
+func paymentDate(k int) time.Time {
+	first := startDate.AddDate(0, 1, 0)
+	if !firstPaymentDate.IsZero() {
+		first = firstPaymentDate.Time
+	}
+
+	if 12%paymentsPerYear == 0 {
+		return first.AddDate(0, (k-1)*12/paymentsPerYear, 0)
+	}
+
+	days := int(math.Round(365 / float64(paymentsPerYear)))
+
+	return first.AddDate(0, 0, (k-1)*days)
+}
+
+// groupByYear splits the schedule by the calendar year of each payment,
+// returning the years in order.
+func groupByYear(rows []ScheduleRow) ([]int, map[int][]ScheduleRow) {
+	var years []int
+	var byYear = make(map[int][]ScheduleRow)
+
+	for _, r := range rows {
+		y := paymentDate(r.Month).Year()
+		if _, ok := byYear[y]; !ok {
+			years = append(years, y)
+		}
+		byYear[y] = append(byYear[y], r)
+	}
+
+	return years, byYear
+}
+
+// displayInterestByYear sums the interest of the schedule by the calendar
+// year it's paid in, for -total-interest-breakdown-by-year.
+func displayInterestByYear(rows []ScheduleRow) {
+	years, byYear := groupByYear(rows)
+
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+
+	fmt.Fprintln(stdout)
+	fmt.Fprintln(w, msg("by-year-header"))
+
+	for _, y := range years {
+		_, interest := scheduleTotals(byYear[y])
+		fmt.Fprintf(w, "%d\t%s\t\n", y, interest)
+	}
+
+	_, total := scheduleTotals(rows)
+	fmt.Fprintf(w, "%s\t%s\t\n", msg("by-year-total"), total)
+
+	w.Flush()
+}
+
+// displayScheduleByYear rolls the schedule up into a row per calendar year,
+// for -schedule-by-year.
+func displayScheduleByYear(rows []ScheduleRow) {
+	years, byYear := groupByYear(rows)
+
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+
+	fmt.Fprintln(stdout)
+	fmt.Fprintln(w, msg("schedule-by-year-header"))
+
+	for _, y := range years {
+		paid, interest := scheduleTotals(byYear[y])
+		last := byYear[y][len(byYear[y])-1]
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t\n", y, paid, interest, paid.Sub(interest), last.Balance)
+	}
+
+	w.Flush()
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// yearRows returns the fields of the rows of the table ending the output,
+// without its header.
+func yearRows(out string) [][]string {
+	table := out[strings.LastIndex(out, "\n\n")+2:]
+
+	var rows [][]string
+	for _, line := range strings.Split(strings.TrimSuffix(table, "\n"), "\n")[1:] {
+		rows = append(rows, strings.Fields(line))
+	}
+
+	return rows
+}
+
+// sumColumn adds up a column of amounts, failing on one that isn't.
+func sumColumn(t *testing.T, rows [][]string, column int) Money {
+	t.Helper()
+
+	var sum Money
+	for _, r := range rows {
+		var m Money
+		if err := m.UnmarshalJSON([]byte(r[column])); err != nil {
+			t.Fatalf("%v in %v", err, r)
+		}
+		sum = sum.Add(m)
+	}
+
+	return sum
+}
+
+// TestInterestByYear checks that the interest of the partial first and
+// last years and the full ones in between add up to the total, which is
+// the interest the schedule pays.
+func TestInterestByYear(t *testing.T) {
+	for _, c := range []struct {
+		loan  string
+		years string
+	}{
+		{"--principal=100000 --periods=30 --interest=6 --start-date=2024-06-15", "2024,2025,2026"},
+		{"--principal=100000 --periods=12 --interest=6 --start-date=2023-12-01", "2024"},
+		{"--principal=100000 --periods=12 --interest=6 --start-date=2024-01-31", "2024,2025"},
+		{"--principal=1000000 --periods=360 --interest=7 --start-date=2024-11-30", ""},
+	} {
+		out, _, _ := runArgs(t, "--type=annuity --total-interest-breakdown-by-year "+c.loan)
+		rows := yearRows(out)
+		years, total := rows[:len(rows)-1], rows[len(rows)-1]
+
+		var names []string
+		for _, r := range years {
+			names = append(names, r[0])
+		}
+		if c.years != "" && strings.Join(names, ",") != c.years {
+			t.Errorf("%s: years %v, want %s", c.loan, names, c.years)
+		}
+
+		data, _, _ := runArgs(t, "--type=annuity --format=json "+c.loan)
+		var r Result
+		if err := json.Unmarshal([]byte(data), &r); err != nil {
+			t.Fatal(err)
+		}
+
+		if sum := sumColumn(t, years, 1); total[0] != "Total" || total[1] != sum.String() || sum != *r.ScheduledOverpayment {
+			t.Errorf("%s: the years sum to %s, the total is %v, the schedule pays %s", c.loan, sum, total, *r.ScheduledOverpayment)
+		}
+	}
+}
+
+// TestScheduleByYear checks that the principal repaid year by year sums to
+// the loan principal, and that the last year ends the loan.
+func TestScheduleByYear(t *testing.T) {
+	for _, c := range []struct {
+		loan  string
+		years int
+	}{
+		{"--type=annuity --principal=100000 --periods=30 --interest=6 --start-date=2024-06-15", 3},
+		{"--type=annuity --principal=250000 --periods=360 --interest=4.5 --start-date=2024-01-01", 31},
+		{"--type=diff --principal=100000 --periods=13 --interest=6 --start-date=2024-01-01", 2},
+	} {
+		out, _, _ := runArgs(t, c.loan+" --schedule-by-year")
+		rows := yearRows(out)
+
+		if len(rows) != c.years || rows[len(rows)-1][4] != "0" {
+			t.Fatalf("%s: %d years\n%s", c.loan, len(rows), out)
+		}
+
+		principal := strings.TrimPrefix(strings.Fields(c.loan)[1], "--principal=")
+		if sum := sumColumn(t, rows, 3).String(); sum != principal {
+			t.Errorf("%s: the years repay %s", c.loan, sum)
+		}
+
+		// the interest and principal add up to what each year paid
+		for _, r := range rows {
+			year := [][]string{r}
+			if sumColumn(t, year, 2).Add(sumColumn(t, year, 3)) != sumColumn(t, year, 1) {
+				t.Errorf("%s: %v", c.loan, r)
+			}
+		}
+	}
+}